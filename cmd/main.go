@@ -28,7 +28,7 @@ func main() {
 	localContext.InitAppContext()
 	ctx := context.GetContext()
 
-	initializers.InitServices(ctx)
+	app := initializers.InitServices(ctx)
 
 	// Swagger metadata
 	docs.SwaggerInfo.Title = "Order Management Service"
@@ -50,9 +50,12 @@ func main() {
 	server.Static("/public", "./public")
 
 	routes.InitServer(server)
-	err := server.StartServer(config.GetString(ctx, "server.name"))
-	if err != nil {
-		log.Panic(i18n.Translate(ctx, "Failed to start server: "), err)
-	}
 
+	// Started last and stopped first, so no requests arrive while draining
+	app.Add(initializers.HTTPServer(server.Engine))
+
+	if err := app.Run(ctx); err != nil {
+		log.Panic(i18n.Translate(ctx, "OMS stopped with errors: "), err)
+	}
+	log.Infof(i18n.Translate(ctx, "OMS stopped"))
 }
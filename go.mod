@@ -7,14 +7,17 @@ toolchain go1.24.4
 require (
 	github.com/aws/aws-sdk-go v1.44.140
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.81.0
+	github.com/bufbuild/protocompile v0.14.1
 	github.com/gin-gonic/gin v1.10.1
 	github.com/google/uuid v1.6.0
 	github.com/omniful/go_commons v0.6.23
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/swaggo/files v1.0.1
 	github.com/swaggo/gin-swagger v1.6.0
 	github.com/swaggo/swag v1.16.4
 	go.mongodb.org/mongo-driver v1.17.4
 	go.mongodb.org/mongo-driver/v2 v2.2.2
+	google.golang.org/protobuf v1.34.2
 )
 
 require (
@@ -114,7 +117,6 @@ require (
 	golang.org/x/tools v0.21.1-0.20240508182429-e35e4ccd0d2d // indirect
 	google.golang.org/genproto v0.0.0-20230110181048-76db0878b65f // indirect
 	google.golang.org/grpc v1.65.0 // indirect
-	google.golang.org/protobuf v1.34.2 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
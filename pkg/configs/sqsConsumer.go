@@ -8,10 +8,12 @@ import (
 	"path/filepath"
 
 	"github.com/aditya-goyal-omniful/oms/pkg/database"
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
 	"github.com/aditya-goyal-omniful/oms/pkg/utils"
 	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/omniful/go_commons/config"
 
 	"github.com/omniful/go_commons/i18n"
 	"github.com/omniful/go_commons/log"
@@ -21,6 +23,13 @@ import (
 var consumer *sqs.Consumer
 var err error
 
+var (
+	cancelConsumer context.CancelFunc
+
+	// sqsInflight counts the batches being processed so shutdown can wait for them
+	sqsInflight helpers.Inflight
+)
+
 func ConsumerInit(ctx context.Context) {
 	sqsQueue := GetSqs()
 	consumer, err = sqs.NewConsumer(
@@ -42,13 +51,41 @@ func ConsumerInit(ctx context.Context) {
 }
 
 func StartConsumer(ctx context.Context) {
-	consumer.Start(ctx)
+	// Polling outlives the startup context and ends with StopConsumer
+	consumerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cancelConsumer = cancel
+
+	consumer.Start(consumerCtx)
 	log.Infof(i18n.Translate(ctx, "SQS consumer started"))
 }
 
+// StopConsumer stops polling SQS, waits until ctx expires for the batches
+// being processed and closes the consumer. Messages not processed stay on
+// the queue and are redelivered after their visibility timeout.
+func StopConsumer(ctx context.Context) error {
+	if consumer == nil {
+		return nil
+	}
+	cancelConsumer()
+
+	err := sqsInflight.Drain(ctx)
+	if err != nil {
+		log.Warnf(i18n.Translate(ctx, "SQS consumer stopped with %d batches in flight"), sqsInflight.Count())
+	}
+
+	consumer.Close()
+	log.Infof(i18n.Translate(ctx, "SQS consumer stopped"))
+	return err
+}
+
 type queueHandler struct{}
 
 func (h *queueHandler) Process(ctx context.Context, msgs *[]sqs.Message) error {
+	if !sqsInflight.Begin() {
+		return helpers.ErrDraining
+	}
+	defer sqsInflight.End()
+
 	for _, msg := range *msgs {
 		// Parse message payload
 		var payload struct {
@@ -103,7 +140,12 @@ func (h *queueHandler) Process(ctx context.Context, msgs *[]sqs.Message) error {
 		log.Infof(i18n.Translate(ctx, "Starting to parse CSV file: %s"), tmpFile)
 
 		// Parse the CSV file
-		err = utils.ParseCSV(tmpFile, ctx, database.Collection)
+		collection, err := database.GetMongoCollection(config.GetString(ctx, "mongo.dbname"), config.GetString(ctx, "mongo.collectionName"))
+		if err != nil {
+			log.Errorf(i18n.Translate(ctx, "failed to get orders collection: %v"), err)
+			continue
+		}
+		err = utils.ParseCSV(tmpFile, ctx, collection)
 		if err != nil {
 			log.Errorf(i18n.Translate(ctx, "failed to parse CSV file: %v"), err)
 			continue
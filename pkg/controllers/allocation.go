@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var AllocationPolicyStore helpers.AllocationPolicyStore = helpers.RealAllocationPolicyStore{}
+
+// UpsertAllocationPolicy godoc
+// @Summary Set the tenant's allocation policy
+// @Description Chooses which held order gets scarce stock first: `fifo` (oldest first), `priority_first` (highest priority, then earliest ship-by, then oldest; the default) or `smallest_first` (smallest quantity, then oldest).
+// @Tags Allocation
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param policy body models.AllocationPolicy true "Allocation policy"
+// @Success 200 {object} models.AllocationPolicy
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save policy"
+// @Router /allocation/policy [put]
+func UpsertAllocationPolicy(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var policy models.AllocationPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	policy.TenantID = tenantID
+	policy.UpdatedAt = time.Now()
+
+	if err := AllocationPolicyStore.Save(c.Request.Context(), policy); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save allocation policy")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), policy)
+}
+
+// GetAllocationPolicy godoc
+// @Summary Get the tenant's allocation policy
+// @Description Returns the tenant's allocation policy, or the default policy if none was set.
+// @Tags Allocation
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {object} models.AllocationPolicy
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 500 {object} map[string]string "Failed to fetch policy"
+// @Router /allocation/policy [get]
+func GetAllocationPolicy(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	policy, err := AllocationPolicyStore.Get(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch allocation policy")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), policy)
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var BackorderPolicyStore helpers.BackorderPolicyStore = helpers.RealBackorderPolicyStore{}
+
+// UpsertBackorderPolicy godoc
+// @Summary Allow or disallow backorders
+// @Description Stores a backorder policy for a SKU or a seller (exactly one of sku_id / seller_id). Held orders covered by an allowed policy become `backordered`.
+// @Tags Backorders
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param policy body models.BackorderPolicy true "Backorder policy"
+// @Success 200 {object} models.BackorderPolicy
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save policy"
+// @Router /backorders/policies [post]
+func UpsertBackorderPolicy(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var policy models.BackorderPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	if policy.PolicyID == uuid.Nil {
+		policy.PolicyID = uuid.New()
+	}
+	policy.TenantID = tenantID
+	policy.UpdatedAt = time.Now()
+
+	err = BackorderPolicyStore.Save(c.Request.Context(), policy)
+	if errors.Is(err, helpers.ErrInvalidBackorderPolicy) {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Exactly one of sku_id or seller_id is required")})
+		return
+	}
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save backorder policy")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), policy)
+}
+
+// GetBackorderPolicies godoc
+// @Summary List backorder policies
+// @Tags Backorders
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {array} models.BackorderPolicy
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 500 {object} map[string]string "Failed to fetch policies"
+// @Router /backorders/policies [get]
+func GetBackorderPolicies(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	policies, err := BackorderPolicyStore.List(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch backorder policies")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), policies)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var DeadLetters services.DeadLetterReplayer = services.RealDeadLetterReplayer{}
+
+// ListDeadLetters godoc
+// @Summary List dead-lettered Kafka messages
+// @Description Returns messages that failed all in-consumer attempts, with their source topic, partition, offset, error and attempt count, oldest first. Replayed messages are left out unless `include_replayed` is set.
+// @Tags Admin
+// @Produce json
+// @Param topic query string false "Source topic, e.g. order.created"
+// @Param include_replayed query bool false "Include messages already replayed"
+// @Param limit query int false "Maximum number of messages (default 100, max 1000)"
+// @Success 200 {array} models.DeadLetter
+// @Failure 400 {object} map[string]string "Invalid query parameter"
+// @Failure 500 {object} map[string]string "Failed to list dead letters"
+// @Router /admin/dlq [get]
+func ListDeadLetters(c *gin.Context) {
+	filter := models.DeadLetterFilter{Topic: c.Query("topic")}
+
+	if s := c.Query("include_replayed"); s != "" {
+		includeReplayed, err := strconv.ParseBool(s)
+		if err != nil {
+			c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid include_replayed")})
+			return
+		}
+		filter.IncludeReplayed = includeReplayed
+	}
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid limit")})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	letters, err := DeadLetters.List(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to list dead letters:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to list dead letters")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), letters)
+}
+
+// ReplayDeadLetters godoc
+// @Summary Replay dead-lettered Kafka messages
+// @Description Publishes the selected dead letters back to their source topic with the original key and headers, plus `X-DLQ-Replay-Of`. Without `dead_letter_ids` all messages not replayed yet are replayed, optionally for one `topic`, up to `limit` (default 100). A message that fails again is dead-lettered again.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param filter body models.DeadLetterFilter true "Dead letters to replay"
+// @Success 200 {object} models.DeadLetterReplayResult
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 500 {object} map[string]string "Failed to replay dead letters"
+// @Router /admin/dlq/replay [post]
+func ReplayDeadLetters(c *gin.Context) {
+	var filter models.DeadLetterFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	result, err := DeadLetters.Replay(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to replay dead letters:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to replay dead letters")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), result)
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+type mockDeadLetterReplayer struct {
+	err    error
+	filter models.DeadLetterFilter
+}
+
+func (m *mockDeadLetterReplayer) List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	m.filter = filter
+	return []models.DeadLetter{}, m.err
+}
+
+func (m *mockDeadLetterReplayer) Replay(ctx context.Context, filter models.DeadLetterFilter) (models.DeadLetterReplayResult, error) {
+	m.filter = filter
+	return models.DeadLetterReplayResult{}, m.err
+}
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		replayer       *mockDeadLetterReplayer
+		expectedStatus int
+		expectedTopic  string
+	}{
+		{
+			name:           "List Dead Letters",
+			method:         http.MethodGet,
+			path:           "/admin/dlq?topic=order.created&include_replayed=true&limit=10",
+			replayer:       &mockDeadLetterReplayer{},
+			expectedStatus: http.StatusOK,
+			expectedTopic:  "order.created",
+		},
+		{
+			name:           "Invalid Limit",
+			method:         http.MethodGet,
+			path:           "/admin/dlq?limit=5000",
+			replayer:       &mockDeadLetterReplayer{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "List Fails",
+			method:         http.MethodGet,
+			path:           "/admin/dlq",
+			replayer:       &mockDeadLetterReplayer{err: errors.New("mongo down")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Replay By Topic",
+			method:         http.MethodPost,
+			path:           "/admin/dlq/replay",
+			body:           `{"topic": "order.created", "limit": 50}`,
+			replayer:       &mockDeadLetterReplayer{},
+			expectedStatus: http.StatusOK,
+			expectedTopic:  "order.created",
+		},
+		{
+			name:           "Invalid Replay Body",
+			method:         http.MethodPost,
+			path:           "/admin/dlq/replay",
+			body:           `{"dead_letter_ids": ["not-a-uuid"]}`,
+			replayer:       &mockDeadLetterReplayer{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Replay Fails",
+			method:         http.MethodPost,
+			path:           "/admin/dlq/replay",
+			body:           `{}`,
+			replayer:       &mockDeadLetterReplayer{err: errors.New("kafka down")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			DeadLetters = tc.replayer
+
+			router := gin.Default()
+			router.GET("/admin/dlq", ListDeadLetters)
+			router.POST("/admin/dlq/replay", ReplayDeadLetters)
+
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("[%s] expected status %d, got %d", tc.name, tc.expectedStatus, w.Code)
+			}
+			if tc.replayer.filter.Topic != tc.expectedTopic {
+				t.Errorf("[%s] expected topic %q, got %q", tc.name, tc.expectedTopic, tc.replayer.filter.Topic)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+)
+
+// Health godoc
+// @Summary Service health
+// @Description Reports the state of the IMS circuit breaker and bulkhead, and the validation cache hit/miss counts. The status is `degraded` while the breaker is not closed.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Service status and IMS breaker state"
+// @Router /health [get]
+func Health(c *gin.Context) {
+	ims := helpers.GetIMSHealth()
+
+	status := "ok"
+	if ims.Breaker.State != helpers.BreakerClosed {
+		status = "degraded"
+	}
+
+	c.JSON(int(http.StatusOK), gin.H{
+		i18n.Translate(c, "status"):           status,
+		i18n.Translate(c, "ims"):              ims,
+		i18n.Translate(c, "validation_cache"): helpers.GetValidationCacheStats(),
+	})
+}
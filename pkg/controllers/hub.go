@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var HubLocationStore helpers.HubLocationStore = helpers.RealHubLocationStore{}
+
+// UpsertHubLocation godoc
+// @Summary Set a hub's location
+// @Description Stores latitude/longitude and served regions for one of the tenant's hubs, used for nearest-hub allocation, and its daily `cutoff_time` (HH:MM in `timezone`) used for ship-by deadlines.
+// @Tags Hubs
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param hub_id path string true "Hub ID"
+// @Param hub body models.Hub true "Hub location"
+// @Success 200 {object} models.Hub
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save hub"
+// @Router /hubs/{hub_id}/location [put]
+func UpsertHubLocation(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	hubID, err := uuid.Parse(c.Param("hub_id"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid hub ID")})
+		return
+	}
+
+	var hub models.Hub
+	if err := c.ShouldBindJSON(&hub); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+	hub.HubID = hubID
+	hub.TenantID = tenantID
+	hub.UpdatedAt = time.Now()
+
+	if err := HubLocationStore.SaveHub(c.Request.Context(), hub); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save hub location")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), hub)
+}
+
+// GetHubLocations godoc
+// @Summary List hub locations
+// @Tags Hubs
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {array} models.Hub
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 500 {object} map[string]string "Failed to fetch hubs"
+// @Router /hubs [get]
+func GetHubLocations(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	hubs, err := HubLocationStore.ListHubs(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch hubs")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), hubs)
+}
+
+// UpsertPostalCentroids godoc
+// @Summary Load postal code centroids
+// @Description Stores approximate coordinates for postal codes, used when a shipping address has no coordinates.
+// @Tags Hubs
+// @Accept json
+// @Produce json
+// @Param centroids body []models.PostalCentroid true "Postal code centroids"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save centroids"
+// @Router /hubs/postal-centroids [post]
+func UpsertPostalCentroids(c *gin.Context) {
+	var centroids []models.PostalCentroid
+	if err := c.ShouldBindJSON(&centroids); err != nil || len(centroids) == 0 {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	if err := HubLocationStore.SaveCentroids(c.Request.Context(), centroids); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save postal centroids")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), gin.H{
+		i18n.Translate(c, "message"): i18n.Translate(c, "Postal centroids saved"),
+		i18n.Translate(c, "count"):   len(centroids),
+	})
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var KitStore helpers.KitStore = helpers.RealKitStore{}
+
+// UpsertKit godoc
+// @Summary Define a kit SKU
+// @Description Stores the component SKUs and quantities of a kit. Orders for the kit SKU are expanded into component lines for the inventory check.
+// @Tags Kits
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param kit body models.Kit true "Kit definition"
+// @Success 200 {object} models.Kit
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save kit"
+// @Router /kits [post]
+func UpsertKit(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var kit models.Kit
+	if err := c.ShouldBindJSON(&kit); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+	kit.TenantID = tenantID
+	kit.UpdatedAt = time.Now()
+
+	if err := KitStore.Save(c.Request.Context(), kit); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save kit")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), kit)
+}
+
+// GetKits godoc
+// @Summary List kit definitions
+// @Tags Kits
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {array} models.Kit
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 500 {object} map[string]string "Failed to fetch kits"
+// @Router /kits [get]
+func GetKits(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	kits, err := KitStore.List(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch kits")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), kits)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"time"
 
 	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
@@ -17,12 +18,17 @@ var (
 	OrderFetcher helpers.OrderFetcher = helpers.RealFetcher{}
 	SKUValidator helpers.SKUValidator = helpers.RealValidator{}
 	OrderPublisher services.OrderPublisher = services.RealPublisher{}
+	PreorderGate helpers.PreorderGate = helpers.RealPreorderGate{}
+	KitExpander helpers.KitExpander = helpers.RealKitExpander{}
+	OrderCanceller helpers.OrderCanceller = helpers.RealCanceller{}
+	OrderShipper helpers.OrderShipper = helpers.RealShipper{}
+	SLAPlanner helpers.SLAPlanner = helpers.RealSLAPlanner{}
 )
 
 
 // CreateOrder godoc
 // @Summary Create a new order (async via Kafka)
-// @Description Accepts an order payload, validates SKU and Hub with IMS, sets status to `on_hold`, and publishes to Kafka for further processing.
+// @Description Accepts an order payload, picks a hub via routing rules when `hub_id` is omitted, validates SKU and Hub with IMS, sets status to `on_hold` (or `pre_order` before the SKU's launch date), computes `ship_by` from the tenant's SLA policy for the order's `priority` and the hub cut-off, and publishes to Kafka for further processing.
 // @Tags Orders
 // @Accept json
 // @Produce json
@@ -30,7 +36,9 @@ var (
 // @Param order body models.Order true "Order payload (OrderID optional; generated if missing)"
 // @Success 202 {object} map[string]interface{} "Accepted with order_id and status"
 // @Failure 400 {object} map[string]string "Invalid input or missing fields"
+// @Failure 409 {object} map[string]string "Pre-order cap reached"
 // @Failure 500 {object} map[string]string "Internal server error while publishing"
+// @Failure 503 {object} map[string]string "IMS circuit breaker open"
 // @Router /orders [post]
 func CreateOrder(c *gin.Context) {
 	var order models.Order
@@ -48,24 +56,89 @@ func CreateOrder(c *gin.Context) {
 		return
 	}
 
+	order.TenantID = tenantID
+	// Splitting and routing are decided by OMS, never by the client
+	order.IsSplit = false
+	order.FulfilmentOrders = nil
+	order.HubRouted = false
 
-	// Validate SKU and Hub via Redis + IMS
-	isValid, err := SKUValidator.Validate(c.Request.Context(), order.SKUID, order.HubID, tenantID)
-	if err != nil || !isValid {
-		log.Warnf(i18n.Translate(c, "Invalid SKU or Hub: sku_id=%s, hub_id=%s"), order.SKUID, order.HubID)
-		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid SKU ID or Hub ID")})
+	// Kit SKUs are expanded into their component lines for the inventory check
+	order, err = KitExpander.Expand(c.Request.Context(), order)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to expand kit:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to create order")})
 		return
 	}
 
-	order.Status = "on_hold"
+	// Pick a hub via the tenant's routing rules when the client did not choose one
+	if order.HubID == uuid.Nil {
+		decision, err := HubRouter.Route(c.Request.Context(), order)
+		if helpers.IsIMSUnavailable(err) {
+			c.JSON(int(http.StatusServiceUnavailable), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Inventory service unavailable, please retry later")})
+			return
+		}
+		if err != nil {
+			log.WithError(err).Warn(i18n.Translate(c, "Failed to route order:"))
+			c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "No hub available for order")})
+			return
+		}
+		order.HubID = decision.HubID
+		order.HubRouted = true
+	}
+
+	// Validate SKU (or kit components) and Hub via Redis + IMS
+	for _, line := range helpers.ComponentOrders(order) {
+		isValid, err := SKUValidator.Validate(c.Request.Context(), line.SKUID, line.HubID, tenantID)
+		if helpers.IsIMSUnavailable(err) {
+			c.JSON(int(http.StatusServiceUnavailable), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Inventory service unavailable, please retry later")})
+			return
+		}
+		if err != nil || !isValid {
+			log.Warnf(i18n.Translate(c, "Invalid SKU or Hub: sku_id=%s, hub_id=%s"), line.SKUID, line.HubID)
+			c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid SKU ID or Hub ID")})
+			return
+		}
+	}
 
 	if order.OrderID == uuid.Nil {
 		order.OrderID = uuid.New()
 	}
 
+	// Orders for SKUs that have not launched yet are accepted as pre-orders
+	status, err := PreorderGate.Admit(c.Request.Context(), order)
+	if errors.Is(err, helpers.ErrPreorderCapReached) {
+		c.JSON(int(http.StatusConflict), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Pre-order limit reached for SKU")})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to check pre-order status:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to create order")})
+		return
+	}
+
+	order.Status = status
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+
+	// Deadline from the tenant's SLA for the order's priority and the hub cut-off
+	planned, err := SLAPlanner.Plan(c.Request.Context(), order)
+	if err != nil {
+		log.WithError(err).Warn(i18n.Translate(c, "Failed to compute ship-by deadline:"))
+	} else {
+		order = planned
+	}
 
 	// Push to Kafka
-	OrderPublisher.Publish(&order, tenantIDStr)
+	if err := OrderPublisher.Publish(c.Request.Context(), &order, tenantIDStr); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to queue order:"))
+		if order.Status == "pre_order" {
+			if err := PreorderGate.Release(c.Request.Context(), order); err != nil {
+				log.WithError(err).Warn(i18n.Translate(c, "Failed to release pre-order:"))
+			}
+		}
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to create order")})
+		return
+	}
 
 	c.JSON(int(http.StatusOK), gin.H{
 		i18n.Translate(c, "message"):  i18n.Translate(c, "Order queued for processing"),
@@ -133,3 +206,94 @@ func GetOrders(c *gin.Context) {
 
 	c.JSON(int(http.StatusOK), orders)
 }
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancels the order and its fulfilment orders and releases any inventory reserved for them in IMS.
+// @Tags Orders
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} models.Order "Cancelled order"
+// @Failure 400 {object} map[string]string "Invalid tenant or order ID"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order cannot be cancelled"
+// @Failure 500 {object} map[string]string "Failed to cancel order"
+// @Router /orders/{order_id}/cancel [post]
+func CancelOrder(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid order ID")})
+		return
+	}
+
+	order, err := OrderCanceller.Cancel(c.Request.Context(), tenantID, orderID)
+	switch {
+	case errors.Is(err, helpers.ErrOrderNotFound):
+		c.JSON(int(http.StatusNotFound), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Order not found")})
+	case errors.Is(err, helpers.ErrOrderNotCancellable):
+		c.JSON(int(http.StatusConflict), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Order cannot be cancelled")})
+	case err != nil:
+		log.WithError(err).Error(i18n.Translate(c, "Failed to cancel order:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to cancel order")})
+	default:
+		c.JSON(int(http.StatusOK), order)
+	}
+}
+
+// ShipOrder godoc
+// @Summary Mark an order as shipped
+// @Description Marks a `new_order` and its fulfilment orders as `shipped`, records the carrier and tracking number and publishes `order.shipped`.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param order_id path string true "Order ID"
+// @Param shipment body models.Shipment false "Carrier and tracking number; shipped_at defaults to now"
+// @Success 200 {object} models.Order "Shipped order"
+// @Failure 400 {object} map[string]string "Invalid tenant or order ID, or request body"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order cannot be shipped"
+// @Failure 500 {object} map[string]string "Failed to ship order"
+// @Router /orders/{order_id}/ship [post]
+func ShipOrder(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid order ID")})
+		return
+	}
+
+	var shipment models.Shipment
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&shipment); err != nil {
+			log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+			c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+			return
+		}
+	}
+
+	order, err := OrderShipper.Ship(c.Request.Context(), tenantID, orderID, shipment)
+	switch {
+	case errors.Is(err, helpers.ErrOrderNotFound):
+		c.JSON(int(http.StatusNotFound), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Order not found")})
+	case errors.Is(err, helpers.ErrOrderNotShippable):
+		c.JSON(int(http.StatusConflict), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Order cannot be shipped")})
+	case err != nil:
+		log.WithError(err).Error(i18n.Translate(c, "Failed to ship order:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to ship order")})
+	default:
+		c.JSON(int(http.StatusOK), order)
+	}
+}
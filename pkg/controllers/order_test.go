@@ -110,15 +110,61 @@ func TestGetOrders(t *testing.T) {
 
 type mockValidator struct {
 	isValid bool
+	err     error
 }
 
 func (m mockValidator) Validate(ctx context.Context, skuID, hubID, tenantID uuid.UUID) (bool, error) {
-	return m.isValid, nil
+	return m.isValid, m.err
 }
 
-type mockPublisher struct{}
+type mockRouter struct {
+	err error
+}
+
+func (m mockRouter) Route(ctx context.Context, order models.Order) (models.RoutingDecision, error) {
+	return models.RoutingDecision{HubID: uuid.New(), Action: "default"}, m.err
+}
+
+type mockPreorderGate struct {
+	status   string
+	err      error
+	released *bool
+}
+
+func (m mockPreorderGate) Admit(ctx context.Context, order models.Order) (string, error) {
+	return m.status, m.err
+}
 
-func (m *mockPublisher) Publish(order *models.Order, tenantID string) {}
+func (m mockPreorderGate) Release(ctx context.Context, order models.Order) error {
+	if m.released != nil {
+		*m.released = true
+	}
+	return nil
+}
+
+type mockKitExpander struct{}
+
+func (mockKitExpander) Expand(ctx context.Context, order models.Order) (models.Order, error) {
+	return order, nil
+}
+
+type mockSLAPlanner struct {
+	err error
+}
+
+func (m mockSLAPlanner) Plan(ctx context.Context, order models.Order) (models.Order, error) {
+	return order, m.err
+}
+
+type mockPublisher struct {
+	err       error
+	published models.Order
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, order *models.Order, tenantID string) error {
+	m.published = *order
+	return m.err
+}
 
 func TestCreateOrder(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -133,7 +179,12 @@ func TestCreateOrder(t *testing.T) {
 		args           args
 		mockValidator  helpers.SKUValidator
 		mockPublisher  services.OrderPublisher
+		mockRouter     helpers.HubRouter
+		mockGate       helpers.PreorderGate
+		mockPlanner    helpers.SLAPlanner
 		expectedStatus int
+		wantReleased   bool
+		checkOrder     func(t *testing.T, order models.Order)
 	}{
 		{
 			name: "Success",
@@ -150,6 +201,48 @@ func TestCreateOrder(t *testing.T) {
 			mockPublisher: &mockPublisher{},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "Client Cannot Mark Hub Routed",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id":     uuid.New().String(),
+					"hub_id":     uuid.New().String(),
+					"hub_routed": true,
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator:  mockValidator{isValid: true},
+			mockPublisher:  &mockPublisher{},
+			expectedStatus: http.StatusOK,
+			checkOrder: func(t *testing.T, order models.Order) {
+				if order.HubRouted {
+					t.Error("expected hub_routed from the client to be ignored")
+				}
+			},
+		},
+		{
+			name: "Client Cannot Mark Order Split",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id":   uuid.New().String(),
+					"hub_id":   uuid.New().String(),
+					"is_split": true,
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator:  mockValidator{isValid: true},
+			mockPublisher:  &mockPublisher{},
+			expectedStatus: http.StatusOK,
+			checkOrder: func(t *testing.T, order models.Order) {
+				if order.IsSplit {
+					t.Error("expected is_split from the client to be ignored")
+				}
+			},
+		},
 		{
 			name: "Invalid JSON",
 			args: args{
@@ -192,12 +285,156 @@ func TestCreateOrder(t *testing.T) {
 			mockPublisher: &mockPublisher{},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "IMS Circuit Open",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+					"hub_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator:  mockValidator{err: helpers.ErrCircuitOpen},
+			mockPublisher:  &mockPublisher{},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "Hub Routed When Omitted",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{},
+			mockRouter:    mockRouter{},
+			expectedStatus: http.StatusOK,
+			checkOrder: func(t *testing.T, order models.Order) {
+				if !order.HubRouted {
+					t.Error("expected a routed hub to be marked hub_routed")
+				}
+			},
+		},
+		{
+			name: "No Hub Available",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{},
+			mockRouter:    mockRouter{err: helpers.ErrNoHubAvailable},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Pre-order Accepted",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+					"hub_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{},
+			mockGate:      mockPreorderGate{status: "pre_order"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Pre-order Cap Reached",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+					"hub_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{},
+			mockGate:      mockPreorderGate{err: helpers.ErrPreorderCapReached},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "SLA Lookup Failure Still Accepted",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id":   uuid.New().String(),
+					"hub_id":   uuid.New().String(),
+					"priority": 2,
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{},
+			mockPlanner:   mockSLAPlanner{err: errors.New("mongo down")},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Kafka Publish Failure",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+					"hub_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{err: errors.New("kafka down")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Kafka Publish Failure Releases Pre-order",
+			args: args{
+				body: map[string]interface{}{
+					"sku_id": uuid.New().String(),
+					"hub_id": uuid.New().String(),
+				},
+				headers: map[string]string{
+					"X-Tenant-ID": uuid.New().String(),
+				},
+			},
+			mockValidator: mockValidator{isValid: true},
+			mockPublisher: &mockPublisher{err: errors.New("kafka down")},
+			mockGate:      mockPreorderGate{status: "pre_order"},
+			expectedStatus: http.StatusInternalServerError,
+			wantReleased:   true,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			SKUValidator = tc.mockValidator
 			OrderPublisher = tc.mockPublisher
+			HubRouter = tc.mockRouter
+			KitExpander = mockKitExpander{}
+			released := false
+			gate, _ := tc.mockGate.(mockPreorderGate)
+			if tc.mockGate == nil {
+				gate = mockPreorderGate{status: "on_hold"}
+			}
+			gate.released = &released
+			PreorderGate = gate
+			SLAPlanner = tc.mockPlanner
+			if SLAPlanner == nil {
+				SLAPlanner = mockSLAPlanner{}
+			}
 
 			router := gin.Default()
 			router.POST("/orders", CreateOrder)
@@ -215,6 +452,114 @@ func TestCreateOrder(t *testing.T) {
 			if w.Code != tc.expectedStatus {
 				t.Errorf("[%s] Expected status %d but got %d", tc.name, tc.expectedStatus, w.Code)
 			}
+			if released != tc.wantReleased {
+				t.Errorf("[%s] Expected pre-order released %v but got %v", tc.name, tc.wantReleased, released)
+			}
+			if tc.checkOrder != nil {
+				tc.checkOrder(t, tc.mockPublisher.(*mockPublisher).published)
+			}
+		})
+	}
+}
+
+type mockCanceller struct {
+	err error
+}
+
+func (m mockCanceller) Cancel(ctx context.Context, tenantID, orderID uuid.UUID) (models.Order, error) {
+	return models.Order{OrderID: orderID, TenantID: tenantID, Status: "cancelled"}, m.err
+}
+
+type mockShipper struct {
+	err error
+}
+
+func (m mockShipper) Ship(ctx context.Context, tenantID, orderID uuid.UUID, shipment models.Shipment) (models.Order, error) {
+	return models.Order{OrderID: orderID, TenantID: tenantID, Status: "shipped", Shipment: &shipment}, m.err
+}
+
+func TestShipOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	validTenantID := uuid.New().String()
+	validOrderID := uuid.New().String()
+
+	tests := []struct {
+		name           string
+		tenantID       string
+		orderID        string
+		body           string
+		mockShipper    helpers.OrderShipper
+		expectedStatus int
+	}{
+		{"Invalid Tenant ID", "not-a-uuid", validOrderID, "", mockShipper{}, http.StatusBadRequest},
+		{"Invalid Order ID", validTenantID, "not-a-uuid", "", mockShipper{}, http.StatusBadRequest},
+		{"Invalid Body", validTenantID, validOrderID, `{"carrier": 42}`, mockShipper{}, http.StatusBadRequest},
+		{"Order Not Found", validTenantID, validOrderID, "", mockShipper{err: helpers.ErrOrderNotFound}, http.StatusNotFound},
+		{"Not Shippable", validTenantID, validOrderID, "", mockShipper{err: helpers.ErrOrderNotShippable}, http.StatusConflict},
+		{"Shipper Fails", validTenantID, validOrderID, "", mockShipper{err: errors.New("mock failure")}, http.StatusInternalServerError},
+		{"Success Without Body", validTenantID, validOrderID, "", mockShipper{}, http.StatusOK},
+		{"Success With Tracking", validTenantID, validOrderID, `{"carrier": "DHL", "tracking_number": "JD0146"}`, mockShipper{}, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			OrderShipper = tc.mockShipper
+
+			router := gin.Default()
+			router.POST("/orders/:order_id/ship", ShipOrder)
+
+			req, _ := http.NewRequest(http.MethodPost, "/orders/"+tc.orderID+"/ship", bytes.NewBufferString(tc.body))
+			req.Header.Set("X-Tenant-ID", tc.tenantID)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("[%s] Expected status %d, got %d", tc.name, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	validTenantID := uuid.New().String()
+	validOrderID := uuid.New().String()
+
+	tests := []struct {
+		name           string
+		tenantID       string
+		orderID        string
+		mockCanceller  helpers.OrderCanceller
+		expectedStatus int
+	}{
+		{"Invalid Tenant ID", "not-a-uuid", validOrderID, mockCanceller{}, http.StatusBadRequest},
+		{"Invalid Order ID", validTenantID, "not-a-uuid", mockCanceller{}, http.StatusBadRequest},
+		{"Order Not Found", validTenantID, validOrderID, mockCanceller{err: helpers.ErrOrderNotFound}, http.StatusNotFound},
+		{"Not Cancellable", validTenantID, validOrderID, mockCanceller{err: helpers.ErrOrderNotCancellable}, http.StatusConflict},
+		{"Canceller Fails", validTenantID, validOrderID, mockCanceller{err: errors.New("mock failure")}, http.StatusInternalServerError},
+		{"Success", validTenantID, validOrderID, mockCanceller{}, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			OrderCanceller = tc.mockCanceller
+
+			router := gin.Default()
+			router.POST("/orders/:order_id/cancel", CancelOrder)
+
+			req, _ := http.NewRequest(http.MethodPost, "/orders/"+tc.orderID+"/cancel", nil)
+			req.Header.Set("X-Tenant-ID", tc.tenantID)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("[%s] Expected status %d, got %d", tc.name, tc.expectedStatus, w.Code)
+			}
 		})
 	}
 }
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var SKULaunchStore helpers.SKULaunchStore = helpers.RealSKULaunchStore{}
+
+// UpsertSKULaunch godoc
+// @Summary Schedule a SKU launch
+// @Description Puts a SKU in pre-order mode until `launch_at`. Orders placed before launch are accepted as `pre_order` up to `preorder_cap` units (0 = no cap) and go through the inventory check on the launch date.
+// @Tags Pre-orders
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param launch body models.SKULaunch true "SKU launch"
+// @Success 200 {object} models.SKULaunch
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save launch"
+// @Router /preorders/launches [post]
+func UpsertSKULaunch(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var launch models.SKULaunch
+	if err := c.ShouldBindJSON(&launch); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+	launch.TenantID = tenantID
+	launch.Released = !launch.LaunchAt.After(time.Now())
+	launch.UpdatedAt = time.Now()
+
+	if err := SKULaunchStore.Save(c.Request.Context(), launch); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save SKU launch")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), launch)
+}
+
+// GetSKULaunches godoc
+// @Summary List SKU launches
+// @Tags Pre-orders
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {array} models.SKULaunch
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 500 {object} map[string]string "Failed to fetch launches"
+// @Router /preorders/launches [get]
+func GetSKULaunches(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	launches, err := SKULaunchStore.List(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch SKU launches")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), launches)
+}
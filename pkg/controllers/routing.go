@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var (
+	RoutingRuleStore helpers.RoutingRuleStore = helpers.RealRuleStore{}
+	HubRouter        helpers.HubRouter        = helpers.RealRouter{}
+)
+
+// CreateRoutingRule godoc
+// @Summary Create or replace a routing rule
+// @Description Stores a hub routing rule for the tenant. Rules are evaluated in ascending priority when an order omits `hub_id`.
+// @Tags Routing
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param rule body models.RoutingRule true "Routing rule (rule_id optional; generated if missing)"
+// @Success 201 {object} models.RoutingRule
+// @Failure 400 {object} map[string]string "Invalid input or missing fields"
+// @Failure 500 {object} map[string]string "Failed to save rule"
+// @Router /routing/rules [post]
+func CreateRoutingRule(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var rule models.RoutingRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	if rule.RuleID == uuid.Nil {
+		rule.RuleID = uuid.New()
+		rule.CreatedAt = time.Now()
+	}
+	rule.TenantID = tenantID
+	rule.UpdatedAt = time.Now()
+
+	if err := RoutingRuleStore.Save(c.Request.Context(), rule); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save routing rule")})
+		return
+	}
+
+	c.JSON(int(http.StatusCreated), rule)
+}
+
+// GetRoutingRules godoc
+// @Summary List routing rules
+// @Description Returns the tenant's routing rules in evaluation order.
+// @Tags Routing
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {array} models.RoutingRule
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 500 {object} map[string]string "Failed to fetch rules"
+// @Router /routing/rules [get]
+func GetRoutingRules(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	rules, err := RoutingRuleStore.List(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch routing rules")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), rules)
+}
+
+// DeleteRoutingRule godoc
+// @Summary Delete a routing rule
+// @Tags Routing
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param rule_id path string true "Rule ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid tenant or rule ID"
+// @Failure 404 {object} map[string]string "Rule not found"
+// @Router /routing/rules/{rule_id} [delete]
+func DeleteRoutingRule(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid rule ID")})
+		return
+	}
+
+	deleted, err := RoutingRuleStore.Delete(c.Request.Context(), tenantID, ruleID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to delete routing rule")})
+		return
+	}
+	if !deleted {
+		c.JSON(int(http.StatusNotFound), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Routing rule not found")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), gin.H{i18n.Translate(c, "message"): i18n.Translate(c, "Routing rule deleted")})
+}
+
+// DryRunRouting godoc
+// @Summary Explain hub selection for an order
+// @Description Runs the routing engine for an order payload without creating it and explains which rule matched.
+// @Tags Routing
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param order body models.Order true "Order payload"
+// @Success 200 {object} models.RoutingDecision
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 422 {object} models.RoutingDecision "No hub could be selected"
+// @Router /routing/dry-run [post]
+func DryRunRouting(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var order models.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+	order.TenantID = tenantID
+
+	decision, err := HubRouter.Route(c.Request.Context(), order)
+	if errors.Is(err, helpers.ErrNoHubAvailable) {
+		c.JSON(422, decision)
+		return
+	}
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to route order")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), decision)
+}
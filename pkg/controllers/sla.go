@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var SLAPolicyStore helpers.SLAPolicyStore = helpers.RealSLAPolicyStore{}
+
+// UpsertSLAPolicy godoc
+// @Summary Set the tenant's SLA policy
+// @Description Sets how many days after the hub cut-off orders of each priority must ship. An order gets the level with the highest priority not above its own; orders placed after the hub's `cutoff_time` count from the next day.
+// @Tags SLA
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Param policy body models.SLAPolicy true "SLA policy"
+// @Success 200 {object} models.SLAPolicy
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Failed to save policy"
+// @Router /sla/policy [put]
+func UpsertSLAPolicy(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	var policy models.SLAPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	policy.TenantID = tenantID
+	policy.UpdatedAt = time.Now()
+
+	if err := SLAPolicyStore.Save(c.Request.Context(), policy); err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to save SLA policy")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), policy)
+}
+
+// GetSLAPolicy godoc
+// @Summary Get the tenant's SLA policy
+// @Description Returns the tenant's SLA policy. Tenants without one get no ship-by deadlines.
+// @Tags SLA
+// @Produce json
+// @Param X-Tenant-ID header string true "Tenant ID"
+// @Success 200 {object} models.SLAPolicy
+// @Failure 400 {object} map[string]string "Invalid tenant ID"
+// @Failure 404 {object} map[string]string "No SLA policy"
+// @Failure 500 {object} map[string]string "Failed to fetch policy"
+// @Router /sla/policy [get]
+func GetSLAPolicy(c *gin.Context) {
+	tenantID, err := uuid.Parse(c.GetHeader("X-Tenant-ID"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid tenant ID")})
+		return
+	}
+
+	policy, found, err := SLAPolicyStore.Get(c.Request.Context(), tenantID)
+	if err != nil {
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to fetch SLA policy")})
+		return
+	}
+	if !found {
+		c.JSON(int(http.StatusNotFound), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "No SLA policy for tenant")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), policy)
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// InvalidateValidationCache godoc
+// @Summary Invalidate cached SKU/hub validation results
+// @Description Drops cached IMS validation results for a tenant. With both `sku_id` and `hub_id` only their entries are removed; otherwise every cached result of the tenant is dropped.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param invalidation body helpers.ValidationInvalidation true "Entries to invalidate"
+// @Success 200 {object} map[string]string "Cache invalidated"
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 500 {object} map[string]string "Failed to invalidate cache"
+// @Router /admin/validation-cache/invalidate [post]
+func InvalidateValidationCache(c *gin.Context) {
+	var inv helpers.ValidationInvalidation
+	if err := c.ShouldBindJSON(&inv); err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	if err := helpers.InvalidateValidation(c.Request.Context(), inv); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to invalidate validation cache:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to invalidate cache")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), gin.H{i18n.Translate(c, "message"): i18n.Translate(c, "Validation cache invalidated")})
+}
+
+// GetValidationCacheStats godoc
+// @Summary Validation cache statistics
+// @Description Returns hit, miss and error counts of the validation cache since the service started.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} helpers.ValidationCacheStats "Cache statistics"
+// @Router /admin/validation-cache/stats [get]
+func GetValidationCacheStats(c *gin.Context) {
+	c.JSON(int(http.StatusOK), helpers.GetValidationCacheStats())
+}
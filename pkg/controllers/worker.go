@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/http"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var (
+	WorkerAdmin  services.WorkerAdmin  = services.RealWorkerAdmin{}
+	OrderRetrier services.OrderRetrier = services.RealOrderRetrier{}
+)
+
+// ListWorkers godoc
+// @Summary List background workers
+// @Description Returns every background worker with its interval, pause state, whether this replica leads it, last and next run, and run statistics.
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.WorkerStatus
+// @Router /admin/workers [get]
+func ListWorkers(c *gin.Context) {
+	c.JSON(int(http.StatusOK), WorkerAdmin.List(c.Request.Context()))
+}
+
+// PauseWorker godoc
+// @Summary Pause a background worker
+// @Description Stops the worker on every replica until it is resumed. A run in progress is cancelled.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Worker name"
+// @Success 200 {object} models.WorkerStatus
+// @Failure 404 {object} map[string]string "Worker not found"
+// @Failure 500 {object} map[string]string "Failed to pause worker"
+// @Router /admin/workers/{name}/pause [post]
+func PauseWorker(c *gin.Context) {
+	controlWorker(c, WorkerAdmin.Pause)
+}
+
+// ResumeWorker godoc
+// @Summary Resume a paused background worker
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Worker name"
+// @Success 200 {object} models.WorkerStatus
+// @Failure 404 {object} map[string]string "Worker not found"
+// @Failure 500 {object} map[string]string "Failed to resume worker"
+// @Router /admin/workers/{name}/resume [post]
+func ResumeWorker(c *gin.Context) {
+	controlWorker(c, WorkerAdmin.Resume)
+}
+
+// TriggerWorker godoc
+// @Summary Run a background worker now
+// @Description Asks the replica leading the worker to run it within a few seconds, even while it is paused.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Worker name"
+// @Success 202 {object} models.WorkerStatus
+// @Failure 404 {object} map[string]string "Worker not found"
+// @Failure 500 {object} map[string]string "Failed to trigger worker"
+// @Router /admin/workers/{name}/trigger [post]
+func TriggerWorker(c *gin.Context) {
+	status, err := WorkerAdmin.Trigger(c.Request.Context(), c.Param("name"))
+	if !handleWorkerError(c, err) {
+		return
+	}
+	c.JSON(int(http.StatusAccepted), status)
+}
+
+func controlWorker(c *gin.Context, action func(ctx context.Context, name string) (models.WorkerStatus, error)) {
+	status, err := action(c.Request.Context(), c.Param("name"))
+	if !handleWorkerError(c, err) {
+		return
+	}
+	c.JSON(int(http.StatusOK), status)
+}
+
+// handleWorkerError writes the error response, if any, and reports whether
+// the request succeeded.
+func handleWorkerError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrWorkerNotFound):
+		c.JSON(int(http.StatusNotFound), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Worker not found")})
+	default:
+		log.WithError(err).Error(i18n.Translate(c, "Failed to control worker:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to update worker")})
+	}
+	return false
+}
+
+// RetryOrder godoc
+// @Summary Retry one order now
+// @Description Runs the inventory check for a held order immediately, ignoring its `next_retry_at`. A `failed` order is put back on hold with a fresh attempt count first.
+// @Tags Admin
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} models.Order "Order after the retry"
+// @Failure 400 {object} map[string]string "Invalid order ID"
+// @Failure 404 {object} map[string]string "Order not found"
+// @Failure 409 {object} map[string]string "Order is not waiting for stock"
+// @Failure 500 {object} map[string]string "Failed to retry order"
+// @Router /admin/orders/{order_id}/retry [post]
+func RetryOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid order ID")})
+		return
+	}
+
+	order, err := OrderRetrier.RetryOrder(c.Request.Context(), orderID)
+	switch {
+	case errors.Is(err, helpers.ErrOrderNotFound):
+		c.JSON(int(http.StatusNotFound), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Order not found")})
+	case errors.Is(err, helpers.ErrOrderNotRetryable):
+		c.JSON(int(http.StatusConflict), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Order is not waiting for stock")})
+	case err != nil:
+		log.WithError(err).Error(i18n.Translate(c, "Failed to retry order:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to retry order")})
+	default:
+		c.JSON(int(http.StatusOK), order)
+	}
+}
+
+// RetryOrders godoc
+// @Summary Retry matching orders now
+// @Description Retries held and failed orders matching the filter (tenant, seller, SKU, hub, status, created before), up to `limit` (default 100), in each tenant's allocation policy order.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param filter body models.RetryFilter true "Orders to retry"
+// @Success 200 {object} models.RetryResult
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 500 {object} map[string]string "Failed to retry orders"
+// @Router /admin/orders/retry [post]
+func RetryOrders(c *gin.Context) {
+	var filter models.RetryFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Invalid JSON:"))
+		c.JSON(int(http.StatusBadRequest), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Invalid request body")})
+		return
+	}
+
+	result, err := OrderRetrier.RetryOrders(c.Request.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(c, "Failed to retry orders:"))
+		c.JSON(int(http.StatusInternalServerError), gin.H{i18n.Translate(c, "error"): i18n.Translate(c, "Failed to retry orders")})
+		return
+	}
+
+	c.JSON(int(http.StatusOK), result)
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type mockWorkerAdmin struct {
+	err error
+}
+
+func (m mockWorkerAdmin) List(ctx context.Context) []models.WorkerStatus {
+	return []models.WorkerStatus{{Name: "order-retry"}}
+}
+
+func (m mockWorkerAdmin) Pause(ctx context.Context, name string) (models.WorkerStatus, error) {
+	return models.WorkerStatus{Name: name, Paused: true}, m.err
+}
+
+func (m mockWorkerAdmin) Resume(ctx context.Context, name string) (models.WorkerStatus, error) {
+	return models.WorkerStatus{Name: name}, m.err
+}
+
+func (m mockWorkerAdmin) Trigger(ctx context.Context, name string) (models.WorkerStatus, error) {
+	return models.WorkerStatus{Name: name}, m.err
+}
+
+type mockOrderRetrier struct {
+	err error
+}
+
+func (m mockOrderRetrier) RetryOrder(ctx context.Context, orderID uuid.UUID) (models.Order, error) {
+	return models.Order{OrderID: orderID, Status: "new_order"}, m.err
+}
+
+func (m mockOrderRetrier) RetryOrders(ctx context.Context, filter models.RetryFilter) (models.RetryResult, error) {
+	return models.RetryResult{}, m.err
+}
+
+func TestWorkerAdminEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		admin          mockWorkerAdmin
+		expectedStatus int
+	}{
+		{
+			name:           "List Workers",
+			method:         http.MethodGet,
+			path:           "/admin/workers",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Pause Worker",
+			method:         http.MethodPost,
+			path:           "/admin/workers/order-retry/pause",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Resume Unknown Worker",
+			method:         http.MethodPost,
+			path:           "/admin/workers/unknown/resume",
+			admin:          mockWorkerAdmin{err: services.ErrWorkerNotFound},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Trigger Worker",
+			method:         http.MethodPost,
+			path:           "/admin/workers/order-retry/trigger",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Trigger Fails",
+			method:         http.MethodPost,
+			path:           "/admin/workers/order-retry/trigger",
+			admin:          mockWorkerAdmin{err: errors.New("redis down")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			WorkerAdmin = tc.admin
+
+			router := gin.Default()
+			router.GET("/admin/workers", ListWorkers)
+			router.POST("/admin/workers/:name/pause", PauseWorker)
+			router.POST("/admin/workers/:name/resume", ResumeWorker)
+			router.POST("/admin/workers/:name/trigger", TriggerWorker)
+
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("[%s] expected status %d, got %d", tc.name, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestRetryOrderEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		retrier        mockOrderRetrier
+		expectedStatus int
+	}{
+		{
+			name:           "Invalid Order ID",
+			path:           "/admin/orders/not-a-uuid/retry",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Order Not Found",
+			path:           "/admin/orders/" + uuid.NewString() + "/retry",
+			retrier:        mockOrderRetrier{err: helpers.ErrOrderNotFound},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Order Not Retryable",
+			path:           "/admin/orders/" + uuid.NewString() + "/retry",
+			retrier:        mockOrderRetrier{err: helpers.ErrOrderNotRetryable},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Retry Order",
+			path:           "/admin/orders/" + uuid.NewString() + "/retry",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid Filter Status",
+			path:           "/admin/orders/retry",
+			body:           `{"status": "new_order"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Retry By Filter",
+			path:           "/admin/orders/retry",
+			body:           `{"tenant_id": "` + uuid.NewString() + `", "status": "failed"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Retry By Filter Fails",
+			path:           "/admin/orders/retry",
+			body:           `{}`,
+			retrier:        mockOrderRetrier{err: errors.New("mongo down")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			OrderRetrier = tc.retrier
+
+			router := gin.Default()
+			router.POST("/admin/orders/retry", RetryOrders)
+			router.POST("/admin/orders/:order_id/retry", RetryOrder)
+
+			req, _ := http.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("[%s] expected status %d, got %d", tc.name, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -35,6 +35,16 @@ func ConnectDB(ctx context.Context) {
 	log.Infof(i18n.Translate(ctx, "Connected to MongoDB successfully"))
 }
 
+// DisconnectDB closes the Mongo client, waiting until ctx expires for
+// operations in progress.
+func DisconnectDB(ctx context.Context) error {
+	if mongoClient == nil {
+		return nil
+	}
+	log.Infof(i18n.Translate(ctx, "Disconnecting from MongoDB..."))
+	return mongoClient.Disconnect(ctx)
+}
+
 func GetDB() *mongo.Client {
 	return mongoClient
 }
@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type AllocationPolicyStore interface {
+	Save(ctx context.Context, policy models.AllocationPolicy) error
+	Get(ctx context.Context, tenantID uuid.UUID) (models.AllocationPolicy, error)
+}
+
+type RealAllocationPolicyStore struct{}
+
+func (RealAllocationPolicyStore) Save(ctx context.Context, policy models.AllocationPolicy) error {
+	return SaveAllocationPolicy(ctx, policy)
+}
+
+func (RealAllocationPolicyStore) Get(ctx context.Context, tenantID uuid.UUID) (models.AllocationPolicy, error) {
+	return GetAllocationPolicy(ctx, tenantID)
+}
+
+func SaveAllocationPolicy(ctx context.Context, policy models.AllocationPolicy) error {
+	collection, err := getCollection(ctx, "mongo.allocationPolicyCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": policy.TenantID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": policy}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save allocation policy:"))
+	}
+	return err
+}
+
+// GetAllocationPolicy returns the tenant's policy, or the configured default
+// (`allocation.default_policy`, priority first if unset) when it has none.
+func GetAllocationPolicy(ctx context.Context, tenantID uuid.UUID) (models.AllocationPolicy, error) {
+	policy := models.AllocationPolicy{TenantID: tenantID, Policy: defaultAllocationPolicy(ctx)}
+
+	collection, err := getCollection(ctx, "mongo.allocationPolicyCollectionName")
+	if err != nil {
+		return policy, err
+	}
+
+	err = collection.FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&policy)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return policy, nil
+	}
+	return policy, err
+}
+
+func defaultAllocationPolicy(ctx context.Context) string {
+	if policy := config.GetString(ctx, "allocation.default_policy"); policy != "" {
+		return policy
+	}
+	return models.AllocationPriorityFirst
+}
+
+// SortForAllocation orders held orders in the sequence the policy hands out
+// stock. Ties always fall back to the oldest order, then the order ID, so the
+// sequence is deterministic.
+func SortForAllocation(orders []models.Order, policy string) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		switch policy {
+		case models.AllocationPriorityFirst:
+			if a.Priority != b.Priority {
+				return a.Priority > b.Priority
+			}
+			if due, ok := earlierShipBy(a, b); ok {
+				return due
+			}
+		case models.AllocationSmallestFirst:
+			if a.Quantity != b.Quantity {
+				return a.Quantity < b.Quantity
+			}
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.OrderID.String() < b.OrderID.String()
+	})
+}
+
+// earlierShipBy reports whether a is due before b, and false when their
+// deadlines do not tell them apart. Orders without a deadline go last.
+func earlierShipBy(a, b models.Order) (bool, bool) {
+	switch {
+	case a.ShipBy == nil && b.ShipBy == nil:
+		return false, false
+	case a.ShipBy == nil:
+		return false, true
+	case b.ShipBy == nil:
+		return true, true
+	case a.ShipBy.Equal(*b.ShipBy):
+		return false, false
+	default:
+		return a.ShipBy.Before(*b.ShipBy), true
+	}
+}
+
+type allocationKey struct {
+	hubID uuid.UUID
+	skuID uuid.UUID
+}
+
+// allocationKeys are the SKU/hub lines whose stock the order is waiting for.
+func allocationKeys(order models.Order) []allocationKey {
+	lines := ComponentOrders(order)
+	keys := make([]allocationKey, 0, len(lines))
+	for _, line := range lines {
+		keys = append(keys, allocationKey{hubID: line.HubID, skuID: line.SKUID})
+	}
+	return keys
+}
+
+// AllocateHeldOrders retries held orders tenant by tenant in the sequence of
+// each tenant's allocation policy. Once an order cannot get stock for a
+// SKU/hub, later orders waiting on that SKU/hub are skipped for this run so
+// smaller, newer orders cannot starve the one ahead of them. It returns how
+// many orders were retried.
+func AllocateHeldOrders(ctx context.Context, orders []models.Order, policies AllocationPolicyStore, retry func(context.Context, models.Order) models.Order) int {
+	return NewHeldOrderAllocator(policies, retry, 1, 0).Allocate(ctx, orders)
+}
+
+// HeldOrderAllocator retries pages of held orders through a bounded pool of
+// workers. A tenant's orders are retried one at a time in the sequence of
+// its allocation policy, while different tenants are retried in parallel.
+// SKU/hub lines blocked on one page stay blocked for later pages of the run.
+type HeldOrderAllocator struct {
+	policies     AllocationPolicyStore
+	retry        func(context.Context, models.Order) models.Order
+	workers      int
+	orderTimeout time.Duration
+
+	mu      sync.Mutex
+	tenants map[uuid.UUID]*tenantAllocation
+}
+
+type tenantAllocation struct {
+	policy  string
+	blocked map[allocationKey]bool
+}
+
+// NewHeldOrderAllocator retries with up to workers tenants at once, giving
+// each order orderTimeout (no limit if 0).
+func NewHeldOrderAllocator(policies AllocationPolicyStore, retry func(context.Context, models.Order) models.Order, workers int, orderTimeout time.Duration) *HeldOrderAllocator {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &HeldOrderAllocator{
+		policies:     policies,
+		retry:        retry,
+		workers:      workers,
+		orderTimeout: orderTimeout,
+		tenants:      make(map[uuid.UUID]*tenantAllocation),
+	}
+}
+
+// Allocate retries one page of held orders and returns how many were
+// retried. Pages must be allocated one after another.
+func (a *HeldOrderAllocator) Allocate(ctx context.Context, orders []models.Order) int {
+	byTenant := make(map[uuid.UUID][]models.Order)
+	var tenants []uuid.UUID
+	for _, order := range orders {
+		if _, ok := byTenant[order.TenantID]; !ok {
+			tenants = append(tenants, order.TenantID)
+		}
+		byTenant[order.TenantID] = append(byTenant[order.TenantID], order)
+	}
+
+	var (
+		retried int64
+		wg      sync.WaitGroup
+		slots   = make(chan struct{}, a.workers)
+	)
+	for _, tenantID := range tenants {
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(tenantID uuid.UUID) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			atomic.AddInt64(&retried, int64(a.allocateTenant(ctx, a.tenant(ctx, tenantID), byTenant[tenantID])))
+		}(tenantID)
+	}
+	wg.Wait()
+
+	return int(retried)
+}
+
+func (a *HeldOrderAllocator) tenant(ctx context.Context, tenantID uuid.UUID) *tenantAllocation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if state, ok := a.tenants[tenantID]; ok {
+		return state
+	}
+
+	policy, err := a.policies.Get(ctx, tenantID)
+	if err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to load allocation policy for tenant %s, using %s: %v"), tenantID, policy.Policy, err)
+	}
+	state := &tenantAllocation{policy: policy.Policy, blocked: make(map[allocationKey]bool)}
+	a.tenants[tenantID] = state
+	return state
+}
+
+func (a *HeldOrderAllocator) allocateTenant(ctx context.Context, state *tenantAllocation, held []models.Order) int {
+	SortForAllocation(held, state.policy)
+
+	retried := 0
+	for _, order := range held {
+		if ctx.Err() != nil {
+			break
+		}
+
+		keys := allocationKeys(order)
+		if anyBlocked(state.blocked, keys) {
+			continue
+		}
+
+		updated := a.retryOne(ctx, order)
+		retried++
+		if containsString(retryableStatuses, updated.Status) {
+			for _, key := range keys {
+				state.blocked[key] = true
+			}
+		}
+	}
+	return retried
+}
+
+// retryOne gives the retry of one order orderTimeout. RetryHeldOrder records
+// the outcome on a deadline of its own, so a slow IMS call does not lose it.
+func (a *HeldOrderAllocator) retryOne(ctx context.Context, order models.Order) models.Order {
+	if a.orderTimeout <= 0 {
+		return a.retry(ctx, order)
+	}
+
+	orderCtx, cancel := context.WithTimeout(ctx, a.orderTimeout)
+	defer cancel()
+	return a.retry(orderCtx, order)
+}
+
+func anyBlocked(blocked map[allocationKey]bool, keys []allocationKey) bool {
+	for _, key := range keys {
+		if blocked[key] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+type staticPolicyStore struct {
+	policy string
+}
+
+func (s staticPolicyStore) Save(ctx context.Context, policy models.AllocationPolicy) error {
+	return nil
+}
+
+func (s staticPolicyStore) Get(ctx context.Context, tenantID uuid.UUID) (models.AllocationPolicy, error) {
+	return models.AllocationPolicy{TenantID: tenantID, Policy: s.policy}, nil
+}
+
+func TestSortForAllocation(t *testing.T) {
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	oldLarge := models.Order{OrderID: uuid.New(), Quantity: 10, Priority: 0, CreatedAt: base}
+	newUrgent := models.Order{OrderID: uuid.New(), Quantity: 5, Priority: 2, CreatedAt: base.Add(time.Hour)}
+	newSmall := models.Order{OrderID: uuid.New(), Quantity: 1, Priority: 0, CreatedAt: base.Add(2 * time.Hour)}
+
+	tests := []struct {
+		policy string
+		want   []uuid.UUID
+	}{
+		{models.AllocationFIFO, []uuid.UUID{oldLarge.OrderID, newUrgent.OrderID, newSmall.OrderID}},
+		{models.AllocationPriorityFirst, []uuid.UUID{newUrgent.OrderID, oldLarge.OrderID, newSmall.OrderID}},
+		{models.AllocationSmallestFirst, []uuid.UUID{newSmall.OrderID, newUrgent.OrderID, oldLarge.OrderID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			orders := []models.Order{newSmall, oldLarge, newUrgent}
+			SortForAllocation(orders, tt.policy)
+			for i, order := range orders {
+				if order.OrderID != tt.want[i] {
+					t.Fatalf("position %d: expected %s, got %s", i, tt.want[i], order.OrderID)
+				}
+			}
+		})
+	}
+}
+
+func TestSortForAllocationPrefersEarlierShipBy(t *testing.T) {
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	soon, later := base.Add(24*time.Hour), base.Add(72*time.Hour)
+
+	noDeadline := models.Order{OrderID: uuid.New(), Priority: 1, CreatedAt: base}
+	dueLater := models.Order{OrderID: uuid.New(), Priority: 1, CreatedAt: base.Add(time.Minute), ShipBy: &later}
+	dueSoon := models.Order{OrderID: uuid.New(), Priority: 1, CreatedAt: base.Add(2 * time.Minute), ShipBy: &soon}
+
+	orders := []models.Order{noDeadline, dueLater, dueSoon}
+	SortForAllocation(orders, models.AllocationPriorityFirst)
+
+	want := []uuid.UUID{dueSoon.OrderID, dueLater.OrderID, noDeadline.OrderID}
+	for i, order := range orders {
+		if order.OrderID != want[i] {
+			t.Fatalf("position %d: expected %s, got %s", i, want[i], order.OrderID)
+		}
+	}
+}
+
+func TestAllocateHeldOrdersStopsAtFirstFailure(t *testing.T) {
+	tenant, sku, hub, otherHub := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	large := models.Order{OrderID: uuid.New(), TenantID: tenant, SKUID: sku, HubID: hub, Quantity: 10, CreatedAt: base}
+	small := models.Order{OrderID: uuid.New(), TenantID: tenant, SKUID: sku, HubID: hub, Quantity: 1, CreatedAt: base.Add(time.Minute)}
+	elsewhere := models.Order{OrderID: uuid.New(), TenantID: tenant, SKUID: sku, HubID: otherHub, Quantity: 1, CreatedAt: base.Add(2 * time.Minute)}
+
+	var retried []uuid.UUID
+	retry := func(ctx context.Context, order models.Order) models.Order {
+		retried = append(retried, order.OrderID)
+		if order.Quantity > 5 {
+			order.Status = "on_hold"
+		} else {
+			order.Status = "new_order"
+		}
+		return order
+	}
+
+	count := AllocateHeldOrders(context.Background(), []models.Order{elsewhere, small, large}, staticPolicyStore{models.AllocationFIFO}, retry)
+
+	if count != 2 || len(retried) != 2 || retried[0] != large.OrderID || retried[1] != elsewhere.OrderID {
+		t.Errorf("expected the large order and the other hub's order only, got %v", retried)
+	}
+}
+
+func TestHeldOrderAllocatorPages(t *testing.T) {
+	sku, hub := uuid.New(), uuid.New()
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		retried  []uuid.UUID
+	)
+	retry := func(ctx context.Context, order models.Order) models.Order {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected a per-order deadline")
+		}
+
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		retried = append(retried, order.OrderID)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		order.Status = "on_hold"
+		return order
+	}
+
+	allocator := NewHeldOrderAllocator(staticPolicyStore{models.AllocationFIFO}, retry, 2, time.Second)
+
+	// One order per tenant, all for the same SKU/hub
+	var first []models.Order
+	tenants := make([]uuid.UUID, 4)
+	for i := range tenants {
+		tenants[i] = uuid.New()
+		first = append(first, models.Order{OrderID: uuid.New(), TenantID: tenants[i], SKUID: sku, HubID: hub, CreatedAt: base})
+	}
+	if count := allocator.Allocate(context.Background(), first); count != 4 {
+		t.Fatalf("expected every tenant's order to be retried, got %d", count)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 workers, saw %d", peak)
+	}
+
+	// The SKU/hub stayed held for these tenants, so their later orders wait
+	second := []models.Order{
+		{OrderID: uuid.New(), TenantID: tenants[0], SKUID: sku, HubID: hub, CreatedAt: base.Add(time.Minute)},
+		{OrderID: uuid.New(), TenantID: uuid.New(), SKUID: sku, HubID: hub, CreatedAt: base.Add(time.Minute)},
+	}
+	if count := allocator.Allocate(context.Background(), second); count != 1 {
+		t.Errorf("expected only the new tenant's order to be retried, got %d", count)
+	}
+	if retried[len(retried)-1] != second[1].OrderID {
+		t.Errorf("expected the new tenant's order to be retried last, got %s", retried[len(retried)-1])
+	}
+}
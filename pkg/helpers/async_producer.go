@@ -0,0 +1,257 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/omniful/go_commons/pubsub"
+)
+
+// ErrProducerClosed is the delivery error of messages published after the
+// producer started shutting down.
+var ErrProducerClosed = errors.New("producer is closed")
+
+// PublishFunc sends one message and returns once the broker has it.
+type PublishFunc func(ctx context.Context, msg *pubsub.Message) error
+
+// Delivery is the outcome of a published message, known once Done is
+// closed.
+type Delivery struct {
+	done  chan struct{}
+	err   error
+	flush func() // asks the producer to send its batch without lingering
+}
+
+func newDelivery() *Delivery {
+	return &Delivery{done: make(chan struct{})}
+}
+
+// Delivered returns a Delivery that has already completed with err.
+func Delivered(err error) *Delivery {
+	d := newDelivery()
+	d.complete(err)
+	return d
+}
+
+func (d *Delivery) complete(err error) {
+	d.err = err
+	close(d.done)
+}
+
+// Done is closed once the message has been sent or has failed.
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err is the delivery error, valid once Done is closed.
+func (d *Delivery) Err() error {
+	return d.err
+}
+
+// Wait blocks until the message is delivered or ctx is done. A message
+// still waiting for its batch to fill is sent at once, as the caller is
+// blocked on it. The message is still sent if ctx ends first.
+func (d *Delivery) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return d.err
+	default:
+	}
+
+	if d.flush != nil {
+		d.flush()
+	}
+	select {
+	case <-d.done:
+		return d.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type AsyncProducerConfig struct {
+	Linger     time.Duration // how long a batch waits to fill up once it has a message
+	BatchSize  int           // messages sent together at most
+	BufferSize int           // messages queued before Publish blocks
+
+	// OnDelivery, if set, is called with the outcome of every message
+	OnDelivery func(ctx context.Context, msg *pubsub.Message, err error)
+}
+
+// AsyncProducer queues messages and sends them in batches in the
+// background, so publishers do not wait for the broker one message at a
+// time. A batch is sent when it is full, has lingered long enough or a
+// publisher waits on one of its deliveries; its messages are sent
+// concurrently across keys but in order for each key.
+type AsyncProducer struct {
+	publish PublishFunc
+	cfg     AsyncProducerConfig
+	queue   chan queuedMessage
+	flushes chan struct{}
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type queuedMessage struct {
+	ctx      context.Context
+	msg      *pubsub.Message
+	delivery *Delivery
+}
+
+// NewAsyncProducer starts sending batches with publish until Close.
+func NewAsyncProducer(publish PublishFunc, cfg AsyncProducerConfig) *AsyncProducer {
+	if cfg.Linger <= 0 {
+		cfg.Linger = 10 * time.Millisecond
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.BufferSize < cfg.BatchSize {
+		cfg.BufferSize = cfg.BatchSize
+	}
+
+	p := &AsyncProducer{
+		publish: publish,
+		cfg:     cfg,
+		queue:   make(chan queuedMessage, cfg.BufferSize),
+		flushes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Publish queues msg and returns its Delivery. It blocks while the buffer
+// is full, failing the delivery with ctx's error if ctx ends first.
+func (p *AsyncProducer) Publish(ctx context.Context, msg *pubsub.Message) *Delivery {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return Delivered(ErrProducerClosed)
+	}
+
+	delivery := newDelivery()
+	delivery.flush = p.requestFlush
+	select {
+	case p.queue <- queuedMessage{ctx: context.WithoutCancel(ctx), msg: msg, delivery: delivery}:
+		return delivery
+	case <-ctx.Done():
+		return Delivered(ctx.Err())
+	}
+}
+
+// requestFlush asks run to send what is queued without lingering. Requests
+// made while one is pending are merged.
+func (p *AsyncProducer) requestFlush() {
+	select {
+	case p.flushes <- struct{}{}:
+	default:
+	}
+}
+
+// Pending is the number of messages queued and not yet sent.
+func (p *AsyncProducer) Pending() int {
+	return len(p.queue)
+}
+
+// Close stops accepting messages and sends the ones already queued. It
+// returns ctx's error if they are not all sent before ctx ends.
+func (p *AsyncProducer) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *AsyncProducer) run() {
+	defer close(p.done)
+
+	var batch []queuedMessage
+	linger := time.NewTimer(p.cfg.Linger)
+	linger.Stop()
+	var lingerC <-chan time.Time
+
+	flush := func() {
+		linger.Stop()
+		lingerC = nil
+		p.send(batch)
+		batch = nil
+	}
+	add := func(item queuedMessage) {
+		batch = append(batch, item)
+		if len(batch) == 1 {
+			linger.Reset(p.cfg.Linger)
+			lingerC = linger.C
+		}
+		if len(batch) >= p.cfg.BatchSize {
+			flush()
+		}
+	}
+
+	for {
+		select {
+		case item, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+			add(item)
+		case <-lingerC:
+			flush()
+		case <-p.flushes:
+			// Everything queued before the wait, which includes the message
+			// waited on, goes out now in full batches
+			for queued := len(p.queue); queued > 0; queued-- {
+				item, ok := <-p.queue
+				if !ok {
+					break
+				}
+				add(item)
+			}
+			flush()
+		}
+	}
+}
+
+// send delivers a batch, one goroutine per key so each key's messages keep
+// their order.
+func (p *AsyncProducer) send(batch []queuedMessage) {
+	byKey := make(map[string][]queuedMessage)
+	var keys []string
+	for _, item := range batch {
+		if _, ok := byKey[item.msg.Key]; !ok {
+			keys = append(keys, item.msg.Key)
+		}
+		byKey[item.msg.Key] = append(byKey[item.msg.Key], item)
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(items []queuedMessage) {
+			defer wg.Done()
+			for _, item := range items {
+				err := p.publish(item.ctx, item.msg)
+				if p.cfg.OnDelivery != nil {
+					p.cfg.OnDelivery(item.ctx, item.msg, err)
+				}
+				item.delivery.complete(err)
+			}
+		}(byKey[key])
+	}
+	wg.Wait()
+}
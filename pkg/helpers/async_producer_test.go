@@ -0,0 +1,233 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omniful/go_commons/pubsub"
+)
+
+type recordingBroker struct {
+	mu   sync.Mutex
+	sent []*pubsub.Message
+	fail map[string]error // by message value
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, msg *pubsub.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.fail[string(msg.Value)]; err != nil {
+		return err
+	}
+	b.sent = append(b.sent, msg)
+	return nil
+}
+
+func (b *recordingBroker) values(key string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var values []string
+	for _, msg := range b.sent {
+		if msg.Key == key {
+			values = append(values, string(msg.Value))
+		}
+	}
+	return values
+}
+
+func waitAll(t *testing.T, deliveries []*Delivery) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i, d := range deliveries {
+		if err := d.Wait(ctx); err != nil {
+			t.Fatalf("delivery %d: %v", i, err)
+		}
+	}
+}
+
+func TestAsyncProducerSendsFullBatch(t *testing.T) {
+	broker := &recordingBroker{}
+	producer := NewAsyncProducer(broker.Publish, AsyncProducerConfig{Linger: time.Hour, BatchSize: 3})
+	defer producer.Close(context.Background())
+
+	var deliveries []*Delivery
+	for i := 0; i < 3; i++ {
+		deliveries = append(deliveries, producer.Publish(context.Background(), &pubsub.Message{Key: "k", Value: []byte(fmt.Sprint(i))}))
+	}
+
+	// Sent without waiting for the linger, watched without Wait, which
+	// would send the batch anyway
+	for i, d := range deliveries {
+		select {
+		case <-d.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("delivery %d: expected the full batch sent at once", i)
+		}
+	}
+	if got := broker.values("k"); len(got) != 3 || got[0] != "0" || got[2] != "2" {
+		t.Fatalf("expected 0, 1, 2 in order, got %v", got)
+	}
+}
+
+func TestAsyncProducerSendsAfterLinger(t *testing.T) {
+	broker := &recordingBroker{}
+	producer := NewAsyncProducer(broker.Publish, AsyncProducerConfig{Linger: 20 * time.Millisecond, BatchSize: 100})
+	defer producer.Close(context.Background())
+
+	started := time.Now()
+	delivery := producer.Publish(context.Background(), &pubsub.Message{Key: "k", Value: []byte("1")})
+
+	// Watched without Wait, which would send the batch at once
+	select {
+	case <-delivery.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the batch sent after lingering")
+	}
+	if waited := time.Since(started); waited < 20*time.Millisecond {
+		t.Errorf("expected the batch to linger 20ms, sent after %v", waited)
+	}
+	if err := delivery.Err(); err != nil {
+		t.Errorf("expected the message delivered, got %v", err)
+	}
+}
+
+func TestAsyncProducerWaitSkipsLinger(t *testing.T) {
+	broker := &recordingBroker{}
+	producer := NewAsyncProducer(broker.Publish, AsyncProducerConfig{Linger: time.Hour, BatchSize: 100})
+	defer producer.Close(context.Background())
+
+	queued := producer.Publish(context.Background(), &pubsub.Message{Key: "a", Value: []byte("1")})
+	waited := producer.Publish(context.Background(), &pubsub.Message{Key: "b", Value: []byte("2")})
+
+	waitAll(t, []*Delivery{waited})
+	select {
+	case <-queued.Done():
+	case <-time.After(time.Second):
+		t.Error("expected messages queued before the wait sent with it")
+	}
+}
+
+func TestAsyncProducerSendsKeysConcurrently(t *testing.T) {
+	release := make(chan struct{})
+	broker := &recordingBroker{}
+	producer := NewAsyncProducer(func(ctx context.Context, msg *pubsub.Message) error {
+		if msg.Key == "slow" {
+			<-release
+		}
+		return broker.Publish(ctx, msg)
+	}, AsyncProducerConfig{Linger: time.Hour, BatchSize: 2})
+	defer producer.Close(context.Background())
+	defer close(release)
+
+	slow := producer.Publish(context.Background(), &pubsub.Message{Key: "slow", Value: []byte("1")})
+	fast := producer.Publish(context.Background(), &pubsub.Message{Key: "fast", Value: []byte("2")})
+
+	select {
+	case <-fast.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the fast key sent while the slow key is blocked")
+	}
+	select {
+	case <-slow.Done():
+		t.Error("expected the slow key still sending")
+	default:
+	}
+}
+
+func TestAsyncProducerReportsFailures(t *testing.T) {
+	brokerErr := errors.New("broker unavailable")
+	broker := &recordingBroker{fail: map[string]error{"bad": brokerErr}}
+
+	var mu sync.Mutex
+	reported := map[string]error{}
+	producer := NewAsyncProducer(broker.Publish, AsyncProducerConfig{
+		Linger:    time.Millisecond,
+		BatchSize: 10,
+		OnDelivery: func(ctx context.Context, msg *pubsub.Message, err error) {
+			mu.Lock()
+			reported[string(msg.Value)] = err
+			mu.Unlock()
+		},
+	})
+	defer producer.Close(context.Background())
+
+	good := producer.Publish(context.Background(), &pubsub.Message{Key: "a", Value: []byte("good")})
+	bad := producer.Publish(context.Background(), &pubsub.Message{Key: "b", Value: []byte("bad")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := good.Wait(ctx); err != nil {
+		t.Errorf("expected good message delivered, got %v", err)
+	}
+	if err := bad.Wait(ctx); !errors.Is(err, brokerErr) {
+		t.Errorf("expected broker error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 || reported["good"] != nil || !errors.Is(reported["bad"], brokerErr) {
+		t.Errorf("unexpected delivery reports %v", reported)
+	}
+}
+
+func TestAsyncProducerKeepsOrderPerKey(t *testing.T) {
+	broker := &recordingBroker{}
+	producer := NewAsyncProducer(broker.Publish, AsyncProducerConfig{Linger: time.Millisecond, BatchSize: 7})
+	defer producer.Close(context.Background())
+
+	var deliveries []*Delivery
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("order-%d", i%3)
+		deliveries = append(deliveries, producer.Publish(context.Background(), &pubsub.Message{Key: key, Value: []byte(fmt.Sprint(i))}))
+	}
+	waitAll(t, deliveries)
+
+	for k := 0; k < 3; k++ {
+		key := fmt.Sprintf("order-%d", k)
+		values := broker.values(key)
+		for i, value := range values {
+			if want := fmt.Sprint(k + 3*i); value != want {
+				t.Fatalf("%s: expected %s at %d, got %v", key, want, i, values)
+			}
+		}
+	}
+}
+
+func TestAsyncProducerCloseFlushes(t *testing.T) {
+	broker := &recordingBroker{}
+	producer := NewAsyncProducer(broker.Publish, AsyncProducerConfig{Linger: time.Hour, BatchSize: 100})
+
+	first := producer.Publish(context.Background(), &pubsub.Message{Key: "k", Value: []byte("1")})
+	second := producer.Publish(context.Background(), &pubsub.Message{Key: "k", Value: []byte("2")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := producer.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	select {
+	case <-first.Done():
+	default:
+		t.Fatal("expected queued messages sent by Close")
+	}
+	if first.Err() != nil || second.Err() != nil {
+		t.Errorf("expected both delivered, got %v and %v", first.Err(), second.Err())
+	}
+	if got := broker.values("k"); len(got) != 2 {
+		t.Errorf("expected 2 messages sent, got %v", got)
+	}
+
+	late := producer.Publish(context.Background(), &pubsub.Message{Key: "k", Value: []byte("3")})
+	if err := late.Wait(ctx); !errors.Is(err, ErrProducerClosed) {
+		t.Errorf("expected ErrProducerClosed after Close, got %v", err)
+	}
+}
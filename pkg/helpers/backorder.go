@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrInvalidBackorderPolicy = errors.New("backorder policy needs exactly one of sku_id or seller_id")
+
+type BackorderPolicyStore interface {
+	Save(ctx context.Context, policy models.BackorderPolicy) error
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.BackorderPolicy, error)
+}
+
+type RealBackorderPolicyStore struct{}
+
+func (RealBackorderPolicyStore) Save(ctx context.Context, policy models.BackorderPolicy) error {
+	return SaveBackorderPolicy(ctx, policy)
+}
+
+func (RealBackorderPolicyStore) List(ctx context.Context, tenantID uuid.UUID) ([]models.BackorderPolicy, error) {
+	return GetBackorderPolicies(ctx, tenantID)
+}
+
+func SaveBackorderPolicy(ctx context.Context, policy models.BackorderPolicy) error {
+	if (policy.SKUID == uuid.Nil) == (policy.SellerID == uuid.Nil) {
+		return ErrInvalidBackorderPolicy
+	}
+
+	collection, err := getCollection(ctx, "mongo.backorderPolicyCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": policy.TenantID, "sku_id": policy.SKUID, "seller_id": policy.SellerID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": policy}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save backorder policy:"))
+	}
+	return err
+}
+
+func GetBackorderPolicies(ctx context.Context, tenantID uuid.UUID) ([]models.BackorderPolicy, error) {
+	collection, err := getCollection(ctx, "mongo.backorderPolicyCollectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"tenant_id": tenantID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var policies []models.BackorderPolicy
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// FindBackorderPolicy returns the policy that applies to the order: the SKU
+// policy if there is one, otherwise the seller policy.
+func FindBackorderPolicy(order models.Order, policies []models.BackorderPolicy) (models.BackorderPolicy, bool) {
+	var sellerPolicy *models.BackorderPolicy
+	for i, policy := range policies {
+		if policy.SKUID != uuid.Nil && policy.SKUID == order.SKUID {
+			return policy, true
+		}
+		if policy.SKUID == uuid.Nil && policy.SellerID != uuid.Nil && policy.SellerID == order.SellerID {
+			sellerPolicy = &policies[i]
+		}
+	}
+	if sellerPolicy != nil {
+		return *sellerPolicy, true
+	}
+	return models.BackorderPolicy{}, false
+}
+
+// ExpectedAvailability prefers the date reported by IMS, then the date
+// already promised for a backordered order, and otherwise adds the policy's
+// lead time to now. Keeping the promised date stops it sliding forward on
+// every retry.
+func ExpectedAvailability(reported, promised *time.Time, policy models.BackorderPolicy, now time.Time) time.Time {
+	if reported != nil && !reported.IsZero() {
+		return *reported
+	}
+	if promised != nil && !promised.IsZero() {
+		return *promised
+	}
+	return now.AddDate(0, 0, policy.LeadTimeDays)
+}
+
+// ResolveBackorder decides whether a held order becomes a backorder and
+// returns the expected availability date if it does.
+func ResolveBackorder(ctx context.Context, order models.Order, result InventoryResult) (*time.Time, bool) {
+	policies, err := GetBackorderPolicies(ctx, order.TenantID)
+	if err != nil {
+		log.WithError(err).Warn(i18n.Translate(ctx, "Failed to load backorder policies:"))
+		return nil, false
+	}
+
+	policy, ok := FindBackorderPolicy(order, policies)
+	if !ok || !policy.Allowed {
+		return nil, false
+	}
+
+	var promised *time.Time
+	if order.Status == "backordered" {
+		promised = order.ExpectedAvailableAt
+	}
+	expected := ExpectedAvailability(result.ExpectedAvailableAt, promised, policy, time.Now())
+	return &expected, true
+}
+
+func UpdateOrderBackorder(ctx context.Context, orderID uuid.UUID, expected time.Time) error {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"order_id": orderID}
+	update := bson.M{"$set": bson.M{"status": "backordered", "expected_available_at": expected}}
+
+	_, err = collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "MongoDB update failed:"))
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestFindBackorderPolicy(t *testing.T) {
+	sku := uuid.New()
+	seller := uuid.New()
+	order := models.Order{SKUID: sku, SellerID: seller}
+
+	skuPolicy := models.BackorderPolicy{SKUID: sku, Allowed: false}
+	sellerPolicy := models.BackorderPolicy{SellerID: seller, Allowed: true}
+	otherPolicy := models.BackorderPolicy{SKUID: uuid.New(), Allowed: true}
+
+	tests := []struct {
+		name     string
+		policies []models.BackorderPolicy
+		found    bool
+		allowed  bool
+	}{
+		{"SKU policy beats seller policy", []models.BackorderPolicy{sellerPolicy, skuPolicy}, true, false},
+		{"Seller policy applies", []models.BackorderPolicy{otherPolicy, sellerPolicy}, true, true},
+		{"No matching policy", []models.BackorderPolicy{otherPolicy}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, found := FindBackorderPolicy(order, tt.policies)
+			if found != tt.found || policy.Allowed != tt.allowed {
+				t.Errorf("expected found=%v allowed=%v, got found=%v allowed=%v", tt.found, tt.allowed, found, policy.Allowed)
+			}
+		})
+	}
+}
+
+func TestExpectedAvailability(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	reported := time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)
+	promised := time.Date(2025, 7, 6, 0, 0, 0, 0, time.UTC)
+	policy := models.BackorderPolicy{LeadTimeDays: 7}
+
+	tests := []struct {
+		name     string
+		reported *time.Time
+		promised *time.Time
+		expected time.Time
+	}{
+		{"IMS date wins", &reported, &promised, reported},
+		{"Promised date kept on retry", nil, &promised, promised},
+		{"Lead time for a new backorder", nil, nil, now.AddDate(0, 0, 7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpectedAvailability(tt.reported, tt.promised, policy, now); !got.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateInventoryResult(t *testing.T) {
+	result := EvaluateInventoryResult([]byte(`{"available": false, "expected_available_at": "2025-07-04T00:00:00Z"}`))
+	if result.Status != "on_hold" || result.ExpectedAvailableAt == nil {
+		t.Fatalf("expected on_hold with date, got %+v", result)
+	}
+	if !result.ExpectedAvailableAt.Equal(time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", result.ExpectedAvailableAt)
+	}
+}
@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"github.com/omniful/go_commons/pubsub"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeadLetterReplayHeader marks a replayed message with the dead letter it
+// came from.
+const DeadLetterReplayHeader = "X-DLQ-Replay-Of"
+
+// permanentError marks a failure that retrying cannot fix, such as a
+// malformed message.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the message goes to the dead-letter topic without
+// further attempts.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// HandleWithRetry calls handle up to maxAttempts times, waiting backoff
+// after the first failure and twice as long after each further one. It stops
+// early on a permanent error or once ctx is done, and returns the number of
+// attempts made with the last error.
+func HandleWithRetry(ctx context.Context, maxAttempts int, backoff time.Duration, handle func(context.Context) error) (int, error) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = handle(ctx); err == nil || IsPermanent(err) || attempt >= maxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// NewDeadLetter records why msg could not be handled.
+func NewDeadLetter(msg *pubsub.Message, err error, attempts int) models.DeadLetter {
+	return models.DeadLetter{
+		DeadLetterID: uuid.New(),
+		Topic:        msg.Topic,
+		Partition:    msg.Partition,
+		Offset:       msg.Offset,
+		Key:          msg.Key,
+		Value:        msg.Value,
+		Headers:      msg.Headers,
+		Error:        err.Error(),
+		Attempts:     attempts,
+		FailedAt:     time.Now(),
+	}
+}
+
+// ReplayMessage rebuilds the original message of a dead letter.
+func ReplayMessage(letter models.DeadLetter) *pubsub.Message {
+	headers := make(map[string]string, len(letter.Headers)+1)
+	for k, v := range letter.Headers {
+		headers[k] = v
+	}
+	headers[DeadLetterReplayHeader] = letter.DeadLetterID.String()
+
+	return &pubsub.Message{
+		Topic:   letter.Topic,
+		Key:     letter.Key,
+		Value:   letter.Value,
+		Headers: headers,
+	}
+}
+
+// SaveDeadLetter stores a dead letter read from a dead-letter topic. Saving
+// the same one twice is a no-op, so redelivered DLQ messages are harmless.
+func SaveDeadLetter(ctx context.Context, letter models.DeadLetter) error {
+	collection, err := getCollection(ctx, "mongo.deadLetterCollectionName")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"dead_letter_id": letter.DeadLetterID},
+		bson.M{"$setOnInsert": letter},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save dead letter:"))
+	}
+	return err
+}
+
+// FindDeadLetters returns the dead letters matching filter, oldest first, up
+// to filter.Limit (100 by default).
+func FindDeadLetters(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	collection, err := getCollection(ctx, "mongo.deadLetterCollectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{}
+	if len(filter.DeadLetterIDs) > 0 {
+		query["dead_letter_id"] = bson.M{"$in": filter.DeadLetterIDs}
+	} else if !filter.IncludeReplayed {
+		query["replayed_at"] = bson.M{"$exists": false}
+	}
+	if filter.Topic != "" {
+		query["topic"] = filter.Topic
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "failed_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	letters := []models.DeadLetter{}
+	if err := cursor.All(ctx, &letters); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+func MarkDeadLetterReplayed(ctx context.Context, deadLetterID uuid.UUID, at time.Time) error {
+	collection, err := getCollection(ctx, "mongo.deadLetterCollectionName")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"dead_letter_id": deadLetterID},
+		bson.M{"$set": bson.M{"replayed_at": at}, "$inc": bson.M{"replay_count": 1}},
+	)
+	return err
+}
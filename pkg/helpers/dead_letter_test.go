@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+func TestHandleWithRetry(t *testing.T) {
+	transient := errors.New("mongo unavailable")
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		maxAttempts  int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "Succeeds First Time", failures: 0, maxAttempts: 3, wantAttempts: 1},
+		{name: "Succeeds After Retries", failures: 2, err: transient, maxAttempts: 3, wantAttempts: 3},
+		{name: "Gives Up After Max Attempts", failures: 5, err: transient, maxAttempts: 3, wantAttempts: 3, wantErr: true},
+		{name: "Permanent Error Is Not Retried", failures: 5, err: Permanent(errors.New("bad json")), maxAttempts: 3, wantAttempts: 1, wantErr: true},
+		{name: "At Least One Attempt", failures: 5, err: transient, maxAttempts: 0, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			attempts, err := HandleWithRetry(context.Background(), tc.maxAttempts, time.Millisecond, func(ctx context.Context) error {
+				calls++
+				if calls <= tc.failures {
+					return tc.err
+				}
+				return nil
+			})
+
+			if attempts != tc.wantAttempts || calls != tc.wantAttempts {
+				t.Errorf("expected %d attempts, got %d (%d calls)", tc.wantAttempts, attempts, calls)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDeadLetterRoundTrip(t *testing.T) {
+	msg := &pubsub.Message{
+		Topic:     "order.created",
+		Key:       "order-1",
+		Value:     []byte(`{"order_id":`),
+		Headers:   map[string]string{"X-Tenant-ID": uuid.NewString()},
+		Partition: 2,
+		Offset:    41,
+	}
+
+	letter := NewDeadLetter(msg, Permanent(errors.New("unexpected end of JSON input")), 1)
+	if letter.Topic != msg.Topic || letter.Partition != 2 || letter.Offset != 41 || letter.Attempts != 1 {
+		t.Errorf("dead letter lost message metadata: %+v", letter)
+	}
+	if letter.Error != "unexpected end of JSON input" {
+		t.Errorf("expected the handler error, got %q", letter.Error)
+	}
+
+	replay := ReplayMessage(letter)
+	if replay.Topic != msg.Topic || replay.Key != msg.Key || string(replay.Value) != string(msg.Value) {
+		t.Errorf("replay does not match the original message: %+v", replay)
+	}
+	if replay.Headers["X-Tenant-ID"] != msg.Headers["X-Tenant-ID"] || replay.Headers[DeadLetterReplayHeader] != letter.DeadLetterID.String() {
+		t.Errorf("unexpected replay headers: %v", replay.Headers)
+	}
+	if _, ok := msg.Headers[DeadLetterReplayHeader]; ok {
+		t.Errorf("replay modified the original headers")
+	}
+}
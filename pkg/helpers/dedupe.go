@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+// DedupeStore is the subset of the Redis client used to remember processed
+// messages.
+type DedupeStore interface {
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) (int64, error)
+}
+
+// dedupeClaimTTL bounds how long a message being handled holds its claim,
+// so a consumer that dies mid-message does not block the redelivery for
+// the whole dedupe TTL.
+const dedupeClaimTTL = 5 * time.Minute
+
+// MessageDeduper makes sure a Kafka message is processed once when it is
+// redelivered after a rebalance or restart. The first copy claims the
+// message in Redis before it is handled, so a duplicate arriving while it
+// is still being handled is skipped as well.
+type MessageDeduper struct {
+	store DedupeStore
+	ttl   time.Duration
+}
+
+func NewMessageDeduper(store DedupeStore, ttl time.Duration) *MessageDeduper {
+	return &MessageDeduper{store: store, ttl: ttl}
+}
+
+// MessageID identifies a message by its event_id header, or else by its
+// topic, key and value, which a redelivery repeats.
+func MessageID(msg *pubsub.Message) string {
+	if eventID := msg.Headers["event_id"]; eventID != "" {
+		return msg.Topic + ":" + eventID
+	}
+	sum := sha256.Sum256(msg.Value)
+	return msg.Topic + ":" + msg.Key + ":" + hex.EncodeToString(sum[:16])
+}
+
+func (d *MessageDeduper) key(msg *pubsub.Message) string {
+	return "oms:kafka:processed:" + MessageID(msg)
+}
+
+// Handle claims the message and runs fn, unless another copy already
+// claimed it. The claim is kept for the dedupe TTL once fn succeeds and
+// dropped if it fails, so the message can be retried. If Redis cannot be
+// reached the message is handled anyway, since skipping it could lose it.
+// It reports whether the message was a duplicate.
+func (d *MessageDeduper) Handle(ctx context.Context, msg *pubsub.Message, fn func(ctx context.Context) error) (bool, error) {
+	key := d.key(msg)
+	claimed, err := d.store.SetNX(ctx, key, "processing", dedupeClaimTTL)
+	if err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to claim message %s, handling it without dedupe: %v"), MessageID(msg), err)
+		return false, fn(ctx)
+	}
+	if !claimed {
+		return true, nil
+	}
+
+	if err := fn(ctx); err != nil {
+		if _, delErr := d.store.Del(ctx, key); delErr != nil {
+			log.Warnf(i18n.Translate(ctx, "Failed to drop claim of message %s: %v"), MessageID(msg), delErr)
+		}
+		return false, err
+	}
+
+	if _, err := d.store.Set(ctx, key, "processed", d.ttl); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to record processed message %s: %v"), MessageID(msg), err)
+	}
+	return false, nil
+}
@@ -0,0 +1,150 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/omniful/go_commons/pubsub"
+)
+
+type unavailableDedupeStore struct{}
+
+func (unavailableDedupeStore) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return false, errors.New("redis: connection refused")
+}
+
+func (unavailableDedupeStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return false, errors.New("redis: connection refused")
+}
+
+func (unavailableDedupeStore) Del(ctx context.Context, keys ...string) (int64, error) {
+	return 0, errors.New("redis: connection refused")
+}
+
+func TestMessageDeduperSkipsRedelivery(t *testing.T) {
+	deduper := NewMessageDeduper(&memoryValidationStore{values: map[string]string{}}, time.Hour)
+	msg := &pubsub.Message{Topic: "order.created", Key: "order-1", Value: []byte(`{"order_id":"1"}`)}
+
+	calls := 0
+	handle := func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+
+	for i, wantDuplicate := range []bool{false, true} {
+		duplicate, err := deduper.Handle(context.Background(), msg, handle)
+		if err != nil || duplicate != wantDuplicate {
+			t.Fatalf("delivery %d: expected duplicate=%v, got %v, %v", i+1, wantDuplicate, duplicate, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls)
+	}
+
+	other := &pubsub.Message{Topic: "order.created", Key: "order-1", Value: []byte(`{"order_id":"1","quantity":2}`)}
+	if duplicate, _ := deduper.Handle(context.Background(), other, handle); duplicate {
+		t.Error("expected a message with a different value to be handled")
+	}
+}
+
+func TestMessageDeduperRetriesFailures(t *testing.T) {
+	deduper := NewMessageDeduper(&memoryValidationStore{values: map[string]string{}}, time.Hour)
+	msg := &pubsub.Message{Topic: "order.created", Key: "order-1", Value: []byte("{}")}
+	handlerErr := errors.New("mongo unavailable")
+
+	if _, err := deduper.Handle(context.Background(), msg, func(ctx context.Context) error { return handlerErr }); !errors.Is(err, handlerErr) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+
+	ran := false
+	duplicate, err := deduper.Handle(context.Background(), msg, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if duplicate || err != nil || !ran {
+		t.Errorf("expected a failed message to be retried, got duplicate=%v, err=%v", duplicate, err)
+	}
+}
+
+func TestMessageDeduperSkipsCopyInFlight(t *testing.T) {
+	deduper := NewMessageDeduper(&memoryValidationStore{values: map[string]string{}}, time.Hour)
+	msg := &pubsub.Message{Topic: "order.created", Key: "order-1", Value: []byte("{}")}
+
+	calls := 0
+	handle := func(ctx context.Context) error {
+		calls++
+		// The redelivered copy arrives while the first is being handled
+		duplicate, err := deduper.Handle(ctx, msg, func(ctx context.Context) error {
+			calls++
+			return nil
+		})
+		if !duplicate || err != nil {
+			t.Errorf("expected the copy in flight skipped, got duplicate=%v, err=%v", duplicate, err)
+		}
+		return nil
+	}
+
+	if _, err := deduper.Handle(context.Background(), msg, handle); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the message handled once, handled %d times", calls)
+	}
+}
+
+func TestMessageDeduperFailsOpen(t *testing.T) {
+	deduper := NewMessageDeduper(unavailableDedupeStore{}, time.Hour)
+	msg := &pubsub.Message{Topic: "order.created", Key: "order-1", Value: []byte("{}")}
+
+	for i := 0; i < 2; i++ {
+		ran := false
+		duplicate, err := deduper.Handle(context.Background(), msg, func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+		if duplicate || err != nil || !ran {
+			t.Fatalf("delivery %d: expected the message handled without Redis, got duplicate=%v, err=%v", i+1, duplicate, err)
+		}
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b *pubsub.Message
+		same bool
+	}{
+		{
+			name: "Same Event Id",
+			a:    &pubsub.Message{Topic: "t", Key: "k", Value: []byte("a"), Headers: map[string]string{"event_id": "e1"}},
+			b:    &pubsub.Message{Topic: "t", Key: "k", Value: []byte("b"), Headers: map[string]string{"event_id": "e1"}},
+			same: true,
+		},
+		{
+			name: "Different Event Ids",
+			a:    &pubsub.Message{Topic: "t", Key: "k", Value: []byte("a"), Headers: map[string]string{"event_id": "e1"}},
+			b:    &pubsub.Message{Topic: "t", Key: "k", Value: []byte("a"), Headers: map[string]string{"event_id": "e2"}},
+		},
+		{
+			name: "Same Key And Value",
+			a:    &pubsub.Message{Topic: "t", Key: "k", Value: []byte("a")},
+			b:    &pubsub.Message{Topic: "t", Key: "k", Value: []byte("a")},
+			same: true,
+		},
+		{
+			name: "Different Topics",
+			a:    &pubsub.Message{Topic: "t1", Key: "k", Value: []byte("a")},
+			b:    &pubsub.Message{Topic: "t2", Key: "k", Value: []byte("a")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if same := MessageID(tc.a) == MessageID(tc.b); same != tc.same {
+				t.Errorf("expected same=%v for %q and %q", tc.same, MessageID(tc.a), MessageID(tc.b))
+			}
+		})
+	}
+}
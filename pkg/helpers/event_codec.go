@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas"
+)
+
+// ContentTypeHeader names the Kafka header that says how a message value is
+// encoded. Messages without it are JSON, as everything was before protobuf.
+const (
+	ContentTypeHeader   = "content-type"
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// ContentType reads the content type of a message from its headers,
+// whatever the case of the header name.
+func ContentType(headers map[string]string) string {
+	if value, ok := headers[ContentTypeHeader]; ok {
+		return value
+	}
+	for name, value := range headers {
+		if strings.EqualFold(name, ContentTypeHeader) {
+			return value
+		}
+	}
+	return ""
+}
+
+// EncodeEvent marshals v as the given schema message in the content type.
+// It is validated against the schema first, so a model change the schemas
+// do not cover fails here instead of surprising consumers.
+func EncodeEvent(message string, v interface{}, contentType string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := schemas.ValidateStrict(message, data); err != nil {
+		return nil, err
+	}
+
+	switch contentType {
+	case ContentTypeJSON:
+		return data, nil
+	case ContentTypeProtobuf:
+		return marshalProto(v)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// DecodeEvent unmarshals a consumed message into v. JSON is validated
+// against the schema message first; protobuf is decoded with the generated
+// message, whose parsing does the same checks. Fields the schema does not
+// know are ignored.
+func DecodeEvent(message string, value []byte, contentType string, v interface{}) error {
+	mediaType := ContentTypeJSON
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+		}
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		if err := schemas.Validate(message, value); err != nil {
+			return err
+		}
+		return json.Unmarshal(value, v)
+	case ContentTypeProtobuf:
+		return unmarshalProto(value, v)
+	default:
+		return fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+	}
+}
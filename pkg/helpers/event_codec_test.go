@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas/eventspb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// fullOrder sets every field of models.Order, so the round trip fails if a
+// field is added to the model without being added to the schemas.
+func fullOrder() models.Order {
+	now := time.Date(2026, 10, 19, 9, 30, 0, 123456789, time.UTC)
+	shipBy := now.Add(48 * time.Hour)
+	latitude, longitude := 0.0, 77.59
+	orderID, tenantID := uuid.New(), uuid.New()
+
+	return models.Order{
+		OrderID:             orderID,
+		SKUID:               uuid.New(),
+		HubID:               uuid.New(),
+		HubRouted:           true,
+		SellerID:            uuid.New(),
+		TenantID:            tenantID,
+		Quantity:            4,
+		Price:               12.75,
+		Priority:            2,
+		ShipBy:              &shipBy,
+		SLAStatus:           models.SLAAtRisk,
+		Components:          []models.OrderComponent{{SKUID: uuid.New(), QuantityPerKit: 2, Quantity: 8}},
+		DestinationRegion:   "south",
+		Tags:                []string{"gift", "fragile"},
+		ShippingAddress:     &models.ShippingAddress{PostalCode: "560001", Latitude: &latitude, Longitude: &longitude},
+		Status:              "new_order",
+		IsSplit:             true,
+		ExpectedAvailableAt: &shipBy,
+		RetryCount:          1,
+		LastAttemptAt:       &now,
+		NextRetryAt:         &shipBy,
+		LastError:           "inventory unavailable",
+		Shipment:            &models.Shipment{Carrier: "bluedart", TrackingNumber: "BD123", ShippedAt: now},
+		FulfilmentOrders: []models.FulfilmentOrder{{
+			FulfilmentID: uuid.New(), ParentOrderID: orderID, TenantID: tenantID, SKUID: uuid.New(),
+			HubID: uuid.New(), Quantity: 2, Status: "new_order", CreatedAt: now, UpdatedAt: now,
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func TestEventRoundTrip(t *testing.T) {
+	order := fullOrder()
+	event := NewOrderEvent(models.EventOrderShipped, order, "new_order", "shipment")
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeProtobuf} {
+		t.Run(contentType, func(t *testing.T) {
+			value, err := EncodeEvent(schemas.Order, order, contentType)
+			if err != nil {
+				t.Fatalf("encode order: %v", err)
+			}
+			var gotOrder models.Order
+			if err := DecodeEvent(schemas.Order, value, contentType, &gotOrder); err != nil {
+				t.Fatalf("decode order: %v", err)
+			}
+			if !reflect.DeepEqual(gotOrder, order) {
+				t.Errorf("order changed in round trip\nwant %+v\n got %+v", order, gotOrder)
+			}
+
+			value, err = EncodeEvent(schemas.OrderEvent, event, contentType)
+			if err != nil {
+				t.Fatalf("encode event: %v", err)
+			}
+			var gotEvent models.OrderEvent
+			if err := DecodeEvent(schemas.OrderEvent, value, contentType, &gotEvent); err != nil {
+				t.Fatalf("decode event: %v", err)
+			}
+			if !reflect.DeepEqual(gotEvent, event) {
+				t.Errorf("event changed in round trip\nwant %+v\n got %+v", event, gotEvent)
+			}
+		})
+	}
+}
+
+func TestEncodeEventRejectsFieldsMissingFromSchema(t *testing.T) {
+	order := map[string]interface{}{"order_id": uuid.NewString(), "gift_note": "hi"}
+
+	_, err := EncodeEvent(schemas.Order, order, ContentTypeJSON)
+	if !errors.Is(err, schemas.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	valid, err := EncodeEvent(schemas.Order, fullOrder(), ContentTypeJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validProto, err := EncodeEvent(schemas.Order, fullOrder(), ContentTypeProtobuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A field a newer producer added
+	newerProto := protowire.AppendTag(append([]byte{}, validProto...), 99, protowire.BytesType)
+	newerProto = protowire.AppendString(newerProto, "gift")
+	badUUID, err := proto.Marshal(&eventspb.Order{OrderId: "not-a-uuid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An event whose required payload was never set
+	withoutPayload := orderEventToProto(NewOrderEvent(models.EventOrderCreated, fullOrder(), ""))
+	withoutPayload.Payload = nil
+	missingPayload, err := proto.Marshal(withoutPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		value       []byte
+		contentType string
+		wantErr     error
+	}{
+		{name: "No Content Type Is JSON", value: valid},
+		{name: "JSON With Charset", value: valid, contentType: "application/json; charset=utf-8"},
+		{name: "Schema Violation", value: []byte(`{"order_id": "not-a-uuid"}`), contentType: ContentTypeJSON, wantErr: schemas.ErrInvalid},
+		{name: "JSON Sent As Protobuf", value: valid, contentType: ContentTypeProtobuf, wantErr: schemas.ErrInvalid},
+		{name: "Unknown Protobuf Field Skipped", value: newerProto, contentType: ContentTypeProtobuf},
+		{name: "Bad UUID In Protobuf", value: badUUID, contentType: ContentTypeProtobuf, wantErr: schemas.ErrInvalid},
+		{name: "Unsupported Content Type", value: valid, contentType: "application/avro", wantErr: ErrUnsupportedContentType},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var order models.Order
+			err := DecodeEvent(schemas.Order, tc.value, tc.contentType, &order)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("Missing Required Fields In Protobuf", func(t *testing.T) {
+		var event models.OrderEvent
+		err := DecodeEvent(schemas.OrderEvent, missingPayload, ContentTypeProtobuf, &event)
+		if !errors.Is(err, schemas.ErrInvalid) || !strings.Contains(err.Error(), "payload") {
+			t.Fatalf("expected a schema error naming payload, got %v", err)
+		}
+	})
+}
+
+func TestContentType(t *testing.T) {
+	if got := ContentType(map[string]string{"Content-Type": ContentTypeProtobuf}); got != ContentTypeProtobuf {
+		t.Errorf("expected %s, got %q", ContentTypeProtobuf, got)
+	}
+	if got := ContentType(map[string]string{"source": "order-service"}); got != "" {
+		t.Errorf("expected no content type, got %q", got)
+	}
+}
@@ -0,0 +1,275 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas/eventspb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// marshalProto encodes an order or order event as its generated protobuf
+// message.
+func marshalProto(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case models.Order:
+		return proto.Marshal(orderToProto(v))
+	case *models.Order:
+		return proto.Marshal(orderToProto(*v))
+	case models.OrderEvent:
+		return proto.Marshal(orderEventToProto(v))
+	case *models.OrderEvent:
+		return proto.Marshal(orderEventToProto(*v))
+	default:
+		return nil, fmt.Errorf("%w: no protobuf message for %T", ErrUnsupportedContentType, v)
+	}
+}
+
+// unmarshalProto decodes a protobuf order or order event into v, checking it
+// against the same schema as JSON messages. Fields the generated message
+// does not know are skipped.
+func unmarshalProto(value []byte, v interface{}) error {
+	var err error
+	switch v := v.(type) {
+	case *models.Order:
+		var msg eventspb.Order
+		if err = decodeProto(value, &msg); err == nil {
+			*v, err = orderFromProto(&msg)
+		}
+	case *models.OrderEvent:
+		var msg eventspb.OrderEvent
+		if err = decodeProto(value, &msg); err == nil {
+			*v, err = orderEventFromProto(&msg)
+		}
+	default:
+		return fmt.Errorf("%w: no protobuf message for %T", ErrUnsupportedContentType, v)
+	}
+	if err != nil && !errors.Is(err, schemas.ErrInvalid) {
+		return fmt.Errorf("%w: %v", schemas.ErrInvalid, err)
+	}
+	return err
+}
+
+func decodeProto(value []byte, msg proto.Message) error {
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return err
+	}
+	return schemas.ValidateProto(msg)
+}
+
+func orderToProto(order models.Order) *eventspb.Order {
+	msg := &eventspb.Order{
+		OrderId:             order.OrderID.String(),
+		SkuId:               order.SKUID.String(),
+		HubId:               order.HubID.String(),
+		HubRouted:           order.HubRouted,
+		SellerId:            order.SellerID.String(),
+		TenantId:            order.TenantID.String(),
+		Quantity:            int64(order.Quantity),
+		Price:               order.Price,
+		Priority:            int64(order.Priority),
+		ShipBy:              formatTimePtr(order.ShipBy),
+		SlaStatus:           order.SLAStatus,
+		DestinationRegion:   order.DestinationRegion,
+		Tags:                order.Tags,
+		Status:              order.Status,
+		IsSplit:             order.IsSplit,
+		ExpectedAvailableAt: formatTimePtr(order.ExpectedAvailableAt),
+		RetryCount:          int64(order.RetryCount),
+		LastAttemptAt:       formatTimePtr(order.LastAttemptAt),
+		NextRetryAt:         formatTimePtr(order.NextRetryAt),
+		LastError:           order.LastError,
+		CreatedAt:           formatTime(order.CreatedAt),
+		UpdatedAt:           formatTime(order.UpdatedAt),
+	}
+	for _, component := range order.Components {
+		msg.Components = append(msg.Components, &eventspb.OrderComponent{
+			SkuId:          component.SKUID.String(),
+			QuantityPerKit: int64(component.QuantityPerKit),
+			Quantity:       int64(component.Quantity),
+		})
+	}
+	if address := order.ShippingAddress; address != nil {
+		msg.ShippingAddress = &eventspb.ShippingAddress{
+			PostalCode: address.PostalCode,
+			Latitude:   address.Latitude,
+			Longitude:  address.Longitude,
+		}
+	}
+	if shipment := order.Shipment; shipment != nil {
+		msg.Shipment = &eventspb.Shipment{
+			Carrier:        shipment.Carrier,
+			TrackingNumber: shipment.TrackingNumber,
+			ShippedAt:      formatTime(shipment.ShippedAt),
+		}
+	}
+	for _, fulfilment := range order.FulfilmentOrders {
+		msg.FulfilmentOrders = append(msg.FulfilmentOrders, &eventspb.FulfilmentOrder{
+			FulfilmentId:  fulfilment.FulfilmentID.String(),
+			ParentOrderId: fulfilment.ParentOrderID.String(),
+			TenantId:      fulfilment.TenantID.String(),
+			SkuId:         fulfilment.SKUID.String(),
+			HubId:         fulfilment.HubID.String(),
+			Quantity:      int64(fulfilment.Quantity),
+			Status:        fulfilment.Status,
+			CreatedAt:     formatTime(fulfilment.CreatedAt),
+			UpdatedAt:     formatTime(fulfilment.UpdatedAt),
+		})
+	}
+	return msg
+}
+
+func orderFromProto(msg *eventspb.Order) (models.Order, error) {
+	var p protoParser
+	order := models.Order{
+		OrderID:             p.uuid(msg.GetOrderId()),
+		SKUID:               p.uuid(msg.GetSkuId()),
+		HubID:               p.uuid(msg.GetHubId()),
+		HubRouted:           msg.GetHubRouted(),
+		SellerID:            p.uuid(msg.GetSellerId()),
+		TenantID:            p.uuid(msg.GetTenantId()),
+		Quantity:            int(msg.GetQuantity()),
+		Price:               msg.GetPrice(),
+		Priority:            int(msg.GetPriority()),
+		ShipBy:              p.timePtr(msg.ShipBy),
+		SLAStatus:           msg.GetSlaStatus(),
+		DestinationRegion:   msg.GetDestinationRegion(),
+		Tags:                msg.GetTags(),
+		Status:              msg.GetStatus(),
+		IsSplit:             msg.GetIsSplit(),
+		ExpectedAvailableAt: p.timePtr(msg.ExpectedAvailableAt),
+		RetryCount:          int(msg.GetRetryCount()),
+		LastAttemptAt:       p.timePtr(msg.LastAttemptAt),
+		NextRetryAt:         p.timePtr(msg.NextRetryAt),
+		LastError:           msg.GetLastError(),
+		CreatedAt:           p.time(msg.GetCreatedAt()),
+		UpdatedAt:           p.time(msg.GetUpdatedAt()),
+	}
+	for _, component := range msg.GetComponents() {
+		order.Components = append(order.Components, models.OrderComponent{
+			SKUID:          p.uuid(component.GetSkuId()),
+			QuantityPerKit: int(component.GetQuantityPerKit()),
+			Quantity:       int(component.GetQuantity()),
+		})
+	}
+	if address := msg.GetShippingAddress(); address != nil {
+		order.ShippingAddress = &models.ShippingAddress{
+			PostalCode: address.GetPostalCode(),
+			Latitude:   address.Latitude,
+			Longitude:  address.Longitude,
+		}
+	}
+	if shipment := msg.GetShipment(); shipment != nil {
+		order.Shipment = &models.Shipment{
+			Carrier:        shipment.GetCarrier(),
+			TrackingNumber: shipment.GetTrackingNumber(),
+			ShippedAt:      p.time(shipment.GetShippedAt()),
+		}
+	}
+	for _, fulfilment := range msg.GetFulfilmentOrders() {
+		order.FulfilmentOrders = append(order.FulfilmentOrders, models.FulfilmentOrder{
+			FulfilmentID:  p.uuid(fulfilment.GetFulfilmentId()),
+			ParentOrderID: p.uuid(fulfilment.GetParentOrderId()),
+			TenantID:      p.uuid(fulfilment.GetTenantId()),
+			SKUID:         p.uuid(fulfilment.GetSkuId()),
+			HubID:         p.uuid(fulfilment.GetHubId()),
+			Quantity:      int(fulfilment.GetQuantity()),
+			Status:        fulfilment.GetStatus(),
+			CreatedAt:     p.time(fulfilment.GetCreatedAt()),
+			UpdatedAt:     p.time(fulfilment.GetUpdatedAt()),
+		})
+	}
+	return order, p.err
+}
+
+func orderEventToProto(event models.OrderEvent) *eventspb.OrderEvent {
+	return &eventspb.OrderEvent{
+		EventId:    event.EventID.String(),
+		Type:       event.Type,
+		Version:    int64(event.Version),
+		TenantId:   event.TenantID.String(),
+		OccurredAt: formatTime(event.OccurredAt),
+		Payload: &eventspb.OrderEventPayload{
+			Order:          orderToProto(event.Payload.Order),
+			PreviousStatus: event.Payload.PreviousStatus,
+			ChangedFields:  event.Payload.ChangedFields,
+		},
+	}
+}
+
+func orderEventFromProto(msg *eventspb.OrderEvent) (models.OrderEvent, error) {
+	var p protoParser
+	event := models.OrderEvent{
+		EventID:    p.uuid(msg.GetEventId()),
+		Type:       msg.GetType(),
+		Version:    int(msg.GetVersion()),
+		TenantID:   p.uuid(msg.GetTenantId()),
+		OccurredAt: p.time(msg.GetOccurredAt()),
+		Payload: models.OrderEventPayload{
+			PreviousStatus: msg.GetPayload().GetPreviousStatus(),
+			ChangedFields:  msg.GetPayload().GetChangedFields(),
+		},
+	}
+	if p.err != nil {
+		return event, p.err
+	}
+
+	order, err := orderFromProto(msg.GetPayload().GetOrder())
+	event.Payload.Order = order
+	return event, err
+}
+
+// formatTime writes timestamps the way encoding/json does, so both
+// encodings carry the same strings.
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	value := formatTime(*t)
+	return &value
+}
+
+// protoParser parses the string-encoded UUIDs and timestamps of a message,
+// keeping the first error. Empty strings are zero values, as sent for
+// fields an older producer did not set.
+type protoParser struct {
+	err error
+}
+
+func (p *protoParser) uuid(value string) uuid.UUID {
+	if value == "" || p.err != nil {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		p.err = err
+	}
+	return id
+}
+
+func (p *protoParser) time(value string) time.Time {
+	if value == "" || p.err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		p.err = err
+	}
+	return t
+}
+
+func (p *protoParser) timePtr(value *string) *time.Time {
+	if value == nil {
+		return nil
+	}
+	t := p.time(*value)
+	return &t
+}
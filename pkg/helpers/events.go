@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// OrderEventPublisher delivers order domain events to other services.
+type OrderEventPublisher interface {
+	PublishOrderEvent(ctx context.Context, event models.OrderEvent) error
+}
+
+// Events is replaced by the Kafka publisher when the producer starts. Until
+// then, and in tests, events are dropped.
+var Events OrderEventPublisher = discardEvents{}
+
+type discardEvents struct{}
+
+func (discardEvents) PublishOrderEvent(ctx context.Context, event models.OrderEvent) error {
+	return nil
+}
+
+// NewOrderEvent wraps the order in a versioned event envelope.
+func NewOrderEvent(eventType string, order models.Order, previousStatus string, changedFields ...string) models.OrderEvent {
+	return models.OrderEvent{
+		EventID:    uuid.New(),
+		Type:       eventType,
+		Version:    models.OrderEventVersion,
+		TenantID:   order.TenantID,
+		OccurredAt: time.Now().UTC(),
+		Payload: models.OrderEventPayload{
+			Order:          order,
+			PreviousStatus: previousStatus,
+			ChangedFields:  changedFields,
+		},
+	}
+}
+
+// EmitOrderEvent publishes an event for a change already saved. A failure
+// is logged rather than returned, since the change itself has happened.
+func EmitOrderEvent(ctx context.Context, eventType string, order models.Order, previousStatus string, changedFields ...string) {
+	event := NewOrderEvent(eventType, order, previousStatus, changedFields...)
+	if err := Events.PublishOrderEvent(ctx, event); err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to publish %s for order %s: %v"), eventType, order.OrderID, err)
+	}
+}
+
+// emitStatusChange publishes order.status_changed if the status moved.
+func emitStatusChange(ctx context.Context, order models.Order, previousStatus string) {
+	if order.Status == previousStatus {
+		return
+	}
+	EmitOrderEvent(ctx, models.EventOrderStatusChanged, order, previousStatus)
+}
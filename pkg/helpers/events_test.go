@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+type recordingEvents struct {
+	events []models.OrderEvent
+	err    error
+}
+
+func (r *recordingEvents) PublishOrderEvent(ctx context.Context, event models.OrderEvent) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func TestNewOrderEvent(t *testing.T) {
+	order := models.Order{OrderID: uuid.New(), TenantID: uuid.New(), Status: "new_order"}
+
+	event := NewOrderEvent(models.EventOrderUpdated, order, "", "hub_id")
+
+	if event.EventID == uuid.Nil {
+		t.Errorf("expected an event ID")
+	}
+	if event.Type != models.EventOrderUpdated || event.Version != models.OrderEventVersion {
+		t.Errorf("unexpected type/version %s/%d", event.Type, event.Version)
+	}
+	if event.TenantID != order.TenantID || event.OccurredAt.IsZero() {
+		t.Errorf("expected tenant %s and an occurred_at, got %s and %v", order.TenantID, event.TenantID, event.OccurredAt)
+	}
+	if event.Payload.Order.OrderID != order.OrderID || len(event.Payload.ChangedFields) != 1 || event.Payload.ChangedFields[0] != "hub_id" {
+		t.Errorf("unexpected payload %+v", event.Payload)
+	}
+}
+
+func TestEmitStatusChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		previous   string
+		status     string
+		publishErr error
+		wantEvents int
+	}{
+		{name: "Status Changed", previous: "on_hold", status: "new_order", wantEvents: 1},
+		{name: "Status Unchanged", previous: "on_hold", status: "on_hold", wantEvents: 0},
+		{name: "Publish Failure Is Not Fatal", previous: "on_hold", status: "backordered", publishErr: errors.New("kafka down"), wantEvents: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := &recordingEvents{err: tc.publishErr}
+			Events = recorder
+			defer func() { Events = discardEvents{} }()
+
+			emitStatusChange(context.Background(), models.Order{OrderID: uuid.New(), Status: tc.status}, tc.previous)
+
+			if len(recorder.events) != tc.wantEvents {
+				t.Fatalf("expected %d events, got %d", tc.wantEvents, len(recorder.events))
+			}
+			if tc.wantEvents == 1 {
+				event := recorder.events[0]
+				if event.Type != models.EventOrderStatusChanged || event.Payload.PreviousStatus != tc.previous || event.Payload.Order.Status != tc.status {
+					t.Errorf("unexpected event %+v", event)
+				}
+			}
+		})
+	}
+}
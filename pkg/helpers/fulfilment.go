@@ -0,0 +1,240 @@
+package helpers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// HubAllocation is the quantity planned for a single hub when an order is split.
+type HubAllocation struct {
+	HubID    uuid.UUID
+	Quantity int
+}
+
+// FetchHubAvailability asks IMS how much of the order's SKU every hub of the
+// tenant holds. For kits it reports the number of kits each hub can assemble.
+func FetchHubAvailability(ctx context.Context, order models.Order, inventory InventoryService) ([]models.HubAvailability, error) {
+	if len(order.Components) > 0 {
+		return fetchKitAvailability(ctx, order, inventory)
+	}
+	return fetchSKUAvailability(ctx, order, inventory)
+}
+
+func fetchSKUAvailability(ctx context.Context, order models.Order, inventory InventoryService) ([]models.HubAvailability, error) {
+	availability, err := inventory.Availability(ctx, order.TenantID, order.SKUID)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Availability lookup failed for order %s:"), order.OrderID)
+		return nil, err
+	}
+	return availability, nil
+}
+
+// PlanSplit spreads quantity over the hubs that have stock, starting with the
+// preferred hub and then the hubs holding the most. Whatever cannot be covered
+// is added to the preferred hub's allocation so it can be retried later,
+// keeping one allocation per hub.
+func PlanSplit(quantity int, preferredHub uuid.UUID, availability []models.HubAvailability) []HubAllocation {
+	hubs := make([]models.HubAvailability, 0, len(availability))
+	for _, a := range availability {
+		if a.AvailableQuantity > 0 {
+			hubs = append(hubs, a)
+		}
+	}
+	sort.SliceStable(hubs, func(i, j int) bool {
+		if (hubs[i].HubID == preferredHub) != (hubs[j].HubID == preferredHub) {
+			return hubs[i].HubID == preferredHub
+		}
+		return hubs[i].AvailableQuantity > hubs[j].AvailableQuantity
+	})
+
+	var allocations []HubAllocation
+	remaining := quantity
+	for _, hub := range hubs {
+		if remaining == 0 {
+			break
+		}
+		take := hub.AvailableQuantity
+		if take > remaining {
+			take = remaining
+		}
+		allocations = append(allocations, HubAllocation{HubID: hub.HubID, Quantity: take})
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		for i := range allocations {
+			if allocations[i].HubID == preferredHub {
+				allocations[i].Quantity += remaining
+				return allocations
+			}
+		}
+		allocations = append(allocations, HubAllocation{HubID: preferredHub, Quantity: remaining})
+	}
+	return allocations
+}
+
+// AggregateSplitStatus derives the parent order status from its fulfilment orders.
+func AggregateSplitStatus(children []models.FulfilmentOrder) string {
+	allocated := 0
+	for _, child := range children {
+		if child.Status == "new_order" {
+			allocated++
+		}
+	}
+
+	switch {
+	case len(children) > 0 && allocated == len(children):
+		return "new_order"
+	case allocated > 0:
+		return "partially_allocated"
+	default:
+		return "on_hold"
+	}
+}
+
+func getFulfilmentCollection(ctx context.Context) (*mongo.Collection, error) {
+	return getCollection(ctx, "mongo.fulfilmentCollectionName")
+}
+
+// SplitOrder reserves the order's quantity across every hub of the tenant that
+// holds stock and persists one fulfilment order per hub. It returns nil when
+// no other hub can help, in which case the order simply stays on hold.
+func SplitOrder(ctx context.Context, order models.Order, inventory InventoryService) ([]models.FulfilmentOrder, error) {
+	availability, err := FetchHubAvailability(ctx, order, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := PlanSplit(order.Quantity, order.HubID, availability)
+	if len(allocations) == 1 && allocations[0].HubID == order.HubID {
+		return nil, nil
+	}
+
+	now := time.Now()
+	children := make([]models.FulfilmentOrder, 0, len(allocations))
+	var sagas []*models.InventorySaga
+	for _, allocation := range allocations {
+		child := models.FulfilmentOrder{
+			FulfilmentID:  uuid.New(),
+			ParentOrderID: order.OrderID,
+			TenantID:      order.TenantID,
+			SKUID:         order.SKUID,
+			HubID:         allocation.HubID,
+			Quantity:      allocation.Quantity,
+			Status:        "on_hold",
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		var saga *models.InventorySaga
+		child.Status, saga = checkFulfilmentOrder(ctx, order, child, inventory)
+		if saga != nil {
+			sagas = append(sagas, saga)
+		}
+		children = append(children, child)
+	}
+
+	collection, err := getFulfilmentCollection(ctx)
+	if err != nil {
+		releaseSagas(ctx, sagas, inventory, err)
+		return nil, err
+	}
+
+	docs := make([]interface{}, 0, len(children))
+	for _, child := range children {
+		docs = append(docs, child)
+	}
+	if _, err := collection.InsertMany(ctx, docs); err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save fulfilment orders for order %s:"), order.OrderID)
+		releaseSagas(ctx, sagas, inventory, err)
+		return nil, err
+	}
+
+	for _, saga := range sagas {
+		if err := ConfirmSaga(ctx, saga); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Failed to confirm inventory saga %s:"), saga.SagaID)
+		}
+	}
+
+	log.Infof(i18n.Translate(ctx, "Order %s split into %d fulfilment orders"), order.OrderID, len(children))
+	return children, nil
+}
+
+// RetryFulfilmentOrders re-checks the held fulfilment orders of a split order
+// and returns all of its fulfilment orders with their latest status.
+func RetryFulfilmentOrders(ctx context.Context, order models.Order, inventory InventoryService) ([]models.FulfilmentOrder, error) {
+	collection, err := getFulfilmentCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"parent_order_id": order.OrderID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var children []models.FulfilmentOrder
+	if err := cursor.All(ctx, &children); err != nil {
+		return nil, err
+	}
+
+	for i, child := range children {
+		if child.Status != "on_hold" {
+			continue
+		}
+
+		status, saga := checkFulfilmentOrder(ctx, order, child, inventory)
+		if status == child.Status {
+			continue
+		}
+
+		filter := bson.M{"fulfilment_id": child.FulfilmentID}
+		update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+		if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+			log.WithError(err).Error(i18n.Translate(ctx, "Failed to update fulfilment order %s:"), child.FulfilmentID)
+			releaseSagas(ctx, []*models.InventorySaga{saga}, inventory, err)
+			continue
+		}
+		if saga != nil {
+			if err := ConfirmSaga(ctx, saga); err != nil {
+				log.WithError(err).Warn(i18n.Translate(ctx, "Failed to confirm inventory saga %s:"), saga.SagaID)
+			}
+		}
+		children[i].Status = status
+	}
+
+	return children, nil
+}
+
+// checkFulfilmentOrder reserves the hub and quantity of a single fulfilment
+// order. The returned saga is non-nil when stock was reserved.
+func checkFulfilmentOrder(ctx context.Context, parent models.Order, child models.FulfilmentOrder, inventory InventoryService) (string, *models.InventorySaga) {
+	scoped := parent
+	scoped.HubID = child.HubID
+	scoped.Quantity = child.Quantity
+
+	result, saga := ReserveInventory(ctx, scoped, child.FulfilmentID, inventory)
+	if result.Status == "error" {
+		return child.Status, nil
+	}
+	return result.Status, saga
+}
+
+func releaseSagas(ctx context.Context, sagas []*models.InventorySaga, inventory InventoryService, cause error) {
+	for _, saga := range sagas {
+		if saga == nil {
+			continue
+		}
+		if err := ReleaseSaga(ctx, saga, inventory, cause.Error()); err != nil {
+			log.WithError(err).Error(i18n.Translate(ctx, "Failed to release inventory saga %s:"), saga.SagaID)
+		}
+	}
+}
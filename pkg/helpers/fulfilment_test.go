@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestPlanSplit(t *testing.T) {
+	preferred := uuid.New()
+	other := uuid.New()
+	third := uuid.New()
+
+	tests := []struct {
+		name         string
+		quantity     int
+		availability []models.HubAvailability
+		expected     []HubAllocation
+	}{
+		{
+			name:     "Preferred hub first, then largest stock",
+			quantity: 10,
+			availability: []models.HubAvailability{
+				{HubID: third, AvailableQuantity: 2},
+				{HubID: other, AvailableQuantity: 6},
+				{HubID: preferred, AvailableQuantity: 3},
+			},
+			expected: []HubAllocation{
+				{HubID: preferred, Quantity: 3},
+				{HubID: other, Quantity: 6},
+				{HubID: third, Quantity: 1},
+			},
+		},
+		{
+			name:     "Shortfall stays on preferred hub",
+			quantity: 5,
+			availability: []models.HubAvailability{
+				{HubID: other, AvailableQuantity: 2},
+			},
+			expected: []HubAllocation{
+				{HubID: other, Quantity: 2},
+				{HubID: preferred, Quantity: 3},
+			},
+		},
+		{
+			name:     "Shortfall merged into preferred hub allocation",
+			quantity: 8,
+			availability: []models.HubAvailability{
+				{HubID: preferred, AvailableQuantity: 3},
+				{HubID: other, AvailableQuantity: 2},
+			},
+			expected: []HubAllocation{
+				{HubID: preferred, Quantity: 6},
+				{HubID: other, Quantity: 2},
+			},
+		},
+		{
+			name:         "No stock anywhere",
+			quantity:     4,
+			availability: []models.HubAvailability{{HubID: other, AvailableQuantity: 0}},
+			expected:     []HubAllocation{{HubID: preferred, Quantity: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PlanSplit(tt.quantity, preferred, tt.availability)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %d allocations, got %d: %+v", len(tt.expected), len(result), result)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("allocation %d: expected %+v, got %+v", i, tt.expected[i], result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAggregateSplitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		expected string
+	}{
+		{"All allocated", []string{"new_order", "new_order"}, "new_order"},
+		{"Some allocated", []string{"new_order", "on_hold"}, "partially_allocated"},
+		{"None allocated", []string{"on_hold", "on_hold"}, "on_hold"},
+		{"No children", nil, "on_hold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var children []models.FulfilmentOrder
+			for _, status := range tt.statuses {
+				children = append(children, models.FulfilmentOrder{Status: status})
+			}
+			result := AggregateSplitStatus(children)
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/database"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const earthRadiusKm = 6371.0
+
+type HubLocationStore interface {
+	SaveHub(ctx context.Context, hub models.Hub) error
+	ListHubs(ctx context.Context, tenantID uuid.UUID) ([]models.Hub, error)
+	SaveCentroids(ctx context.Context, centroids []models.PostalCentroid) error
+}
+
+type RealHubLocationStore struct{}
+
+func (RealHubLocationStore) SaveHub(ctx context.Context, hub models.Hub) error {
+	return SaveHubLocation(ctx, hub)
+}
+
+func (RealHubLocationStore) ListHubs(ctx context.Context, tenantID uuid.UUID) ([]models.Hub, error) {
+	return GetHubLocations(ctx, tenantID)
+}
+
+func (RealHubLocationStore) SaveCentroids(ctx context.Context, centroids []models.PostalCentroid) error {
+	return SavePostalCentroids(ctx, centroids)
+}
+
+func SaveHubLocation(ctx context.Context, hub models.Hub) error {
+	collection, err := getCollection(ctx, "mongo.hubCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": hub.TenantID, "hub_id": hub.HubID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": hub}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save hub location:"))
+	}
+	return err
+}
+
+func GetHubLocations(ctx context.Context, tenantID uuid.UUID) ([]models.Hub, error) {
+	collection, err := getCollection(ctx, "mongo.hubCollectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"tenant_id": tenantID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var hubs []models.Hub
+	if err := cursor.All(ctx, &hubs); err != nil {
+		return nil, err
+	}
+	return hubs, nil
+}
+
+func SavePostalCentroids(ctx context.Context, centroids []models.PostalCentroid) error {
+	collection, err := getCollection(ctx, "mongo.postalCentroidCollectionName")
+	if err != nil {
+		return err
+	}
+
+	for _, centroid := range centroids {
+		filter := bson.M{"postal_code": centroid.PostalCode}
+		_, err := collection.UpdateOne(ctx, filter, bson.M{"$set": centroid}, options.Update().SetUpsert(true))
+		if err != nil {
+			log.WithError(err).Error(i18n.Translate(ctx, "Failed to save postal centroid %s:"), centroid.PostalCode)
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveCoordinates returns the address coordinates, falling back to the
+// centroid of its postal code.
+func ResolveCoordinates(ctx context.Context, address *models.ShippingAddress) (lat, lon float64, ok bool) {
+	if address == nil {
+		return 0, 0, false
+	}
+	if address.Latitude != nil && address.Longitude != nil {
+		return *address.Latitude, *address.Longitude, true
+	}
+	if address.PostalCode == "" {
+		return 0, 0, false
+	}
+
+	collection, err := getCollection(ctx, "mongo.postalCentroidCollectionName")
+	if err != nil {
+		return 0, 0, false
+	}
+
+	var centroid models.PostalCentroid
+	if err := collection.FindOne(ctx, bson.M{"postal_code": address.PostalCode}).Decode(&centroid); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Postal centroid lookup failed:"))
+		}
+		return 0, 0, false
+	}
+	return centroid.Latitude, centroid.Longitude, true
+}
+
+// HaversineKm is the great-circle distance between two points in kilometres.
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// AnnotateDistances sets the distance from the destination on every candidate
+// with known location, and drops hubs that do not serve the destination region.
+func AnnotateDistances(candidates []models.HubCandidate, hubs []models.Hub, lat, lon float64, region string) []models.HubCandidate {
+	locations := make(map[uuid.UUID]models.Hub, len(hubs))
+	for _, hub := range hubs {
+		locations[hub.HubID] = hub
+	}
+
+	result := make([]models.HubCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		hub, ok := locations[candidate.HubID]
+		if ok {
+			if region != "" && len(hub.ServedRegions) > 0 && !containsString(hub.ServedRegions, region) {
+				continue
+			}
+			candidate.DistanceKm = HaversineKm(lat, lon, hub.Latitude, hub.Longitude)
+			candidate.HasDistance = true
+		}
+		result = append(result, candidate)
+	}
+	return result
+}
+
+// RankHubCandidates orders hubs so that those able to ship the full quantity
+// come first, then by distance (unknown distances last), then by stock.
+func RankHubCandidates(candidates []models.HubCandidate, quantity int) []models.HubCandidate {
+	ranked := make([]models.HubCandidate, len(candidates))
+	copy(ranked, candidates)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if (a.AvailableQuantity >= quantity) != (b.AvailableQuantity >= quantity) {
+			return a.AvailableQuantity >= quantity
+		}
+		if a.HasDistance != b.HasDistance {
+			return a.HasDistance
+		}
+		if a.HasDistance && a.DistanceKm != b.DistanceKm {
+			return a.DistanceKm < b.DistanceKm
+		}
+		return a.AvailableQuantity > b.AvailableQuantity
+	})
+	return ranked
+}
+
+// AllocateNearestHub moves the order to the closest hub that can ship it in
+// full, before the IMS check-and-update runs. Only hubs picked by OMS are
+// moved; a hub chosen by the client is kept. The order is returned unchanged
+// when its destination cannot be located, no hub ranks better or the better
+// hub fails validation.
+func AllocateNearestHub(ctx context.Context, order models.Order) models.Order {
+	if order.HubID != uuid.Nil && !order.HubRouted {
+		return order
+	}
+
+	lat, lon, ok := ResolveCoordinates(ctx, order.ShippingAddress)
+	if !ok {
+		return order
+	}
+
+	hubs, err := GetHubLocations(ctx, order.TenantID)
+	if err != nil || len(hubs) == 0 {
+		return order
+	}
+
+	availability, err := FetchHubAvailability(ctx, order, Inventory)
+	if err != nil {
+		log.WithError(err).Warn(i18n.Translate(ctx, "Skipping nearest-hub allocation for order %s:"), order.OrderID)
+		return order
+	}
+
+	candidates := AnnotateDistances(toCandidates(availability), hubs, lat, lon, order.DestinationRegion)
+	ranked := RankHubCandidates(candidates, order.Quantity)
+	if len(ranked) == 0 || ranked[0].AvailableQuantity < order.Quantity || ranked[0].HubID == order.HubID {
+		return order
+	}
+
+	moved := order
+	moved.HubID = ranked[0].HubID
+	moved.HubRouted = true
+	for _, line := range ComponentOrders(moved) {
+		valid, err := ValidateSKUAndHubs(ctx, line.SKUID, line.HubID, line.TenantID)
+		if err != nil || !valid {
+			log.Warnf(i18n.Translate(ctx, "Keeping hub %s for order %s, nearest hub %s failed validation"), order.HubID, order.OrderID, moved.HubID)
+			return order
+		}
+	}
+
+	if err := UpdateOrderHub(ctx, order.OrderID, moved.HubID); err != nil {
+		return order
+	}
+
+	log.Infof(i18n.Translate(ctx, "Order %s allocated to nearest hub %s (%.1f km)"), order.OrderID, ranked[0].HubID, ranked[0].DistanceKm)
+	order = moved
+	EmitOrderEvent(ctx, models.EventOrderUpdated, order, "", "hub_id")
+	return order
+}
+
+func UpdateOrderHub(ctx context.Context, orderID, hubID uuid.UUID) error {
+	collection, err := database.GetMongoCollection("oms", "orders")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"order_id": orderID}
+	update := bson.M{"$set": bson.M{"hub_id": hubID, "hub_routed": true, "updated_at": time.Now()}}
+
+	_, err = collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "MongoDB update failed:"))
+	}
+	return err
+}
+
+func toCandidates(availability []models.HubAvailability) []models.HubCandidate {
+	candidates := make([]models.HubCandidate, 0, len(availability))
+	for _, a := range availability {
+		candidates = append(candidates, models.HubCandidate{
+			HubID:             a.HubID,
+			AvailableQuantity: a.AvailableQuantity,
+			ShippingCost:      a.ShippingCost,
+		})
+	}
+	return candidates
+}
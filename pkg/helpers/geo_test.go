@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// Delhi to Mumbai is roughly 1150 km
+	distance := HaversineKm(28.6139, 77.2090, 19.0760, 72.8777)
+	if math.Abs(distance-1150) > 20 {
+		t.Errorf("expected about 1150 km, got %.1f", distance)
+	}
+
+	if d := HaversineKm(12.97, 77.59, 12.97, 77.59); d != 0 {
+		t.Errorf("expected 0 for identical points, got %.4f", d)
+	}
+}
+
+func TestAnnotateAndRankHubCandidates(t *testing.T) {
+	near := uuid.New()
+	far := uuid.New()
+	unlocated := uuid.New()
+	wrongRegion := uuid.New()
+	lowStock := uuid.New()
+
+	hubs := []models.Hub{
+		{HubID: near, Latitude: 28.70, Longitude: 77.10},
+		{HubID: far, Latitude: 19.07, Longitude: 72.87},
+		{HubID: wrongRegion, Latitude: 28.61, Longitude: 77.21, ServedRegions: []string{"south"}},
+		{HubID: lowStock, Latitude: 28.61, Longitude: 77.21},
+	}
+	candidates := []models.HubCandidate{
+		{HubID: far, AvailableQuantity: 50},
+		{HubID: unlocated, AvailableQuantity: 100},
+		{HubID: near, AvailableQuantity: 10},
+		{HubID: wrongRegion, AvailableQuantity: 10},
+		{HubID: lowStock, AvailableQuantity: 1},
+	}
+
+	annotated := AnnotateDistances(candidates, hubs, 28.6139, 77.2090, "north")
+	if len(annotated) != 4 {
+		t.Fatalf("expected hub outside the region to be dropped, got %d candidates", len(annotated))
+	}
+
+	ranked := RankHubCandidates(annotated, 5)
+	expected := []uuid.UUID{near, far, unlocated, lowStock}
+	for i, hubID := range expected {
+		if ranked[i].HubID != hubID {
+			t.Errorf("position %d: expected %s, got %s", i, hubID, ranked[i].HubID)
+		}
+	}
+}
+
+func TestAllocateNearestHubKeepsClientHub(t *testing.T) {
+	order := models.Order{OrderID: uuid.New(), HubID: uuid.New(), Quantity: 1}
+
+	// Returns before locating the order, which would need Mongo and IMS
+	if got := AllocateNearestHub(context.Background(), order); got.HubID != order.HubID {
+		t.Errorf("expected client hub %s kept, got %s", order.HubID, got.HubID)
+	}
+}
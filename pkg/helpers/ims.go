@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/omniful/go_commons/config"
+)
+
+// IMSHealth is what the health endpoint reports about the IMS dependency.
+type IMSHealth struct {
+	Breaker       BreakerSnapshot `json:"breaker"`
+	InFlight      int             `json:"in_flight"`
+	MaxConcurrent int             `json:"max_concurrent"`
+}
+
+var (
+	imsBreaker  = NewCircuitBreaker("ims", BreakerSettings{})
+	imsBulkhead = NewBulkhead(50, 0)
+	imsRetry    = RetrySettings{MaxAttempts: 1}
+)
+
+// InitIMSResilience builds the breaker, bulkhead and retry policy guarding
+// every IMS call from the `ims` config section.
+func InitIMSResilience(ctx context.Context) {
+	imsBreaker = NewCircuitBreaker("ims", BreakerSettings{
+		FailureThreshold: config.GetInt(ctx, "ims.breaker.failure_threshold"),
+		OpenTimeout:      config.GetDuration(ctx, "ims.breaker.open_timeout"),
+		HalfOpenMaxCalls: config.GetInt(ctx, "ims.breaker.half_open_max_calls"),
+		SuccessThreshold: config.GetInt(ctx, "ims.breaker.success_threshold"),
+	})
+	imsBulkhead = NewBulkhead(
+		config.GetInt(ctx, "ims.bulkhead.max_concurrent"),
+		config.GetDuration(ctx, "ims.bulkhead.max_wait"),
+	)
+	imsRetry = RetrySettings{
+		MaxAttempts: config.GetInt(ctx, "ims.retry.max_attempts"),
+		BaseDelay:   config.GetDuration(ctx, "ims.retry.base_delay"),
+		MaxDelay:    config.GetDuration(ctx, "ims.retry.max_delay"),
+	}
+}
+
+func GetIMSHealth() IMSHealth {
+	return IMSHealth{
+		Breaker:       imsBreaker.Snapshot(),
+		InFlight:      imsBulkhead.InFlight(),
+		MaxConcurrent: imsBulkhead.Capacity(),
+	}
+}
+
+// IsIMSUnavailable reports whether err means IMS was not called at all
+// because the breaker is open or the bulkhead is full.
+func IsIMSUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBulkheadFull)
+}
+
+// CallIMS runs call behind the IMS bulkhead and circuit breaker. Idempotent
+// calls are retried with jitter; calls that change stock are attempted once.
+func CallIMS(ctx context.Context, idempotent bool, call func() error) error {
+	attempt := func() error {
+		if err := imsBreaker.Allow(); err != nil {
+			return err
+		}
+		if err := imsBulkhead.Acquire(ctx); err != nil {
+			imsBreaker.Abandon() // not the dependency's fault
+			return err
+		}
+		defer imsBulkhead.Release()
+
+		if err := call(); err != nil {
+			imsBreaker.Failure()
+			return err
+		}
+		imsBreaker.Success()
+		return nil
+	}
+
+	if !idempotent {
+		return attempt()
+	}
+	return RetryWithJitter(ctx, imsRetry, func(err error) bool { return !IsIMSUnavailable(err) }, attempt)
+}
+
+// imsStatusError turns a 5xx IMS response into a breaker failure. Other
+// statuses are answers from a healthy IMS and are left to the caller.
+func imsStatusError(statusCode int) error {
+	if statusCode >= 500 {
+		return fmt.Errorf("ims returned status %d", statusCode)
+	}
+	return nil
+}
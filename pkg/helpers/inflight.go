@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrDraining is returned for work offered after draining has started.
+var ErrDraining = errors.New("shutting down")
+
+// Inflight counts messages being handled so that shutdown can wait for them
+// to finish. Once draining starts no new work is accepted.
+type Inflight struct {
+	mu       sync.Mutex
+	count    int
+	draining bool
+	idle     chan struct{}
+}
+
+// Begin registers a unit of work and reports whether it may go ahead. Every
+// successful Begin must be matched by a call to End.
+func (f *Inflight) Begin() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.draining {
+		return false
+	}
+	f.count++
+	return true
+}
+
+func (f *Inflight) End() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.count--
+	if f.count == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// Count is the number of units of work in progress.
+func (f *Inflight) Count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.count
+}
+
+// Drain stops accepting work and waits until the work in progress is done
+// or ctx expires.
+func (f *Inflight) Drain(ctx context.Context) error {
+	f.mu.Lock()
+	f.draining = true
+	if f.count == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
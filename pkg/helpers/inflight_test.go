@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInflightDrain(t *testing.T) {
+	tests := []struct {
+		name      string
+		pending   int
+		finish    bool
+		wantErr   error
+		wantCount int
+	}{
+		{name: "Nothing In Flight", pending: 0, wantErr: nil},
+		{name: "Work Finishes In Time", pending: 2, finish: true, wantErr: nil},
+		{name: "Deadline Passes First", pending: 1, wantErr: context.DeadlineExceeded, wantCount: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var inflight Inflight
+			for i := 0; i < tc.pending; i++ {
+				if !inflight.Begin() {
+					t.Fatalf("Begin refused work before draining")
+				}
+			}
+
+			if tc.finish {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					for i := 0; i < tc.pending; i++ {
+						inflight.End()
+					}
+				}()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			if err := inflight.Drain(ctx); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got := inflight.Count(); got != tc.wantCount {
+				t.Errorf("expected %d in flight, got %d", tc.wantCount, got)
+			}
+			if inflight.Begin() {
+				t.Errorf("Begin accepted work after draining started")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/httpclient"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// ErrBulkValidationUnsupported is returned when the inventory service has
+// no bulk validation endpoint.
+var ErrBulkValidationUnsupported = errors.New("inventory service has no bulk validation endpoint")
+
+// InventoryService is everything OMS needs from IMS.
+type InventoryService interface {
+	ValidateSKU(ctx context.Context, tenantID, skuID uuid.UUID) (bool, error)
+	ValidateHub(ctx context.Context, tenantID, hubID uuid.UUID) (bool, error)
+	// ValidateOrderLine checks that the SKU can be ordered from the hub.
+	ValidateOrderLine(ctx context.Context, hubID, skuID uuid.UUID) (bool, error)
+	// ValidateOrderLines checks many lines at once. Lines missing from the
+	// result could not be answered.
+	ValidateOrderLines(ctx context.Context, pairs []ValidationPair) (map[ValidationPair]bool, error)
+	// CheckAndReserve reserves the line's quantity at its hub if in stock.
+	// Calls repeating an idempotency key get the first call's answer without
+	// reserving again.
+	CheckAndReserve(ctx context.Context, line models.Order, idempotencyKey string) (InventoryResult, error)
+	// Release gives back a reserved line. Calls repeating an idempotency key
+	// release it once.
+	Release(ctx context.Context, line models.SagaLine, idempotencyKey string) error
+	// Availability reports the SKU's stock at every hub of the tenant.
+	Availability(ctx context.Context, tenantID, skuID uuid.UUID) ([]models.HubAvailability, error)
+}
+
+// ValidationPair is a hub/SKU combination checked by the IMS order validator.
+type ValidationPair struct {
+	HubID uuid.UUID `json:"hub_id"`
+	SKUID uuid.UUID `json:"sku_id"`
+}
+
+// InventoryResult is the outcome of an IMS check-and-update call.
+type InventoryResult struct {
+	Status              string
+	ExpectedAvailableAt *time.Time
+}
+
+// InventoryIdempotencyKey identifies the reservation of one line of an
+// order, or of a fulfilment order (owner), in one reservation attempt. The
+// attempt number is stored on the order and only moves on once a
+// reservation is released, so a redelivered message or a retry after an
+// error repeats the keys of a reservation IMS may already hold and IMS
+// reserves once. After a release the next attempt gets new keys and is
+// answered from current stock.
+func InventoryIdempotencyKey(ownerID uuid.UUID, attempt, line int) string {
+	return ownerID.String() + "-" + strconv.Itoa(attempt) + "-" + strconv.Itoa(line)
+}
+
+// InventoryReleaseKey identifies the release of one reserved line, so a
+// release repeated by the recovery job or a racing cancellation gives the
+// stock back once.
+func InventoryReleaseKey(ownerID uuid.UUID, attempt, line int) string {
+	return InventoryIdempotencyKey(ownerID, attempt, line) + "-release"
+}
+
+// Inventory is the inventory service used by OMS, chosen by
+// InitInventoryService.
+var Inventory InventoryService
+
+// InitInventoryService selects the inventory service from
+// `inventory.provider`: "http" (default) talks to IMS at `client.baseURL`,
+// "fake" serves the in-memory inventory seeded from `inventory.fake_seed_file`.
+func InitInventoryService(ctx context.Context) {
+	switch provider := config.GetString(ctx, "inventory.provider"); provider {
+	case "fake":
+		seedFile := config.GetString(ctx, "inventory.fake_seed_file")
+		fake, err := LoadFakeInventory(seedFile)
+		if err != nil {
+			log.Panicf(i18n.Translate(ctx, "Failed to load fake inventory from %s: %v"), seedFile, err)
+		}
+		Inventory = fake
+		log.Infof(i18n.Translate(ctx, "Using in-memory fake inventory seeded from %s"), seedFile)
+	default:
+		baseURL := config.GetString(ctx, "client.baseURL")
+		Inventory = NewHTTPInventory(httpclient.New(baseURL))
+		log.Infof(i18n.Translate(ctx, "Using IMS at %s"), baseURL)
+	}
+}
@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+// FakeInventorySeed is the initial state of a FakeInventory.
+type FakeInventorySeed struct {
+	SKUs  []FakeSKU   `json:"skus"`
+	Hubs  []FakeHub   `json:"hubs"`
+	Stock []FakeStock `json:"stock"`
+}
+
+type FakeSKU struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	SKUID    uuid.UUID `json:"sku_id"`
+}
+
+type FakeHub struct {
+	TenantID     uuid.UUID `json:"tenant_id"`
+	HubID        uuid.UUID `json:"hub_id"`
+	ShippingCost float64   `json:"shipping_cost"`
+}
+
+type FakeStock struct {
+	HubID    uuid.UUID `json:"hub_id"`
+	SKUID    uuid.UUID `json:"sku_id"`
+	Quantity int       `json:"quantity"`
+}
+
+type stockKey struct {
+	hubID uuid.UUID
+	skuID uuid.UUID
+}
+
+// FakeInventory is an in-memory InventoryService for running OMS and
+// end-to-end tests without IMS. Reservations take stock away immediately and
+// releases put it back. Like IMS, it answers a repeated idempotency key
+// with the reservation already made; answers that reserved nothing are not
+// kept, so a repeated key checks the stock again.
+type FakeInventory struct {
+	mu           sync.Mutex
+	skus         map[uuid.UUID]uuid.UUID // sku -> tenant
+	hubs         map[uuid.UUID]FakeHub
+	stock        map[stockKey]int
+	reservations map[string]InventoryResult // reserving answers by idempotency key
+	releases     map[string]bool            // by idempotency key
+}
+
+func NewFakeInventory(seed FakeInventorySeed) *FakeInventory {
+	fake := &FakeInventory{
+		skus:         make(map[uuid.UUID]uuid.UUID),
+		hubs:         make(map[uuid.UUID]FakeHub),
+		stock:        make(map[stockKey]int),
+		reservations: make(map[string]InventoryResult),
+		releases:     make(map[string]bool),
+	}
+	for _, sku := range seed.SKUs {
+		fake.skus[sku.SKUID] = sku.TenantID
+	}
+	for _, hub := range seed.Hubs {
+		fake.hubs[hub.HubID] = hub
+	}
+	for _, s := range seed.Stock {
+		fake.stock[stockKey{s.HubID, s.SKUID}] = s.Quantity
+	}
+	return fake
+}
+
+// LoadFakeInventory builds a FakeInventory from a JSON seed file.
+func LoadFakeInventory(path string) (*FakeInventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var seed FakeInventorySeed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return nil, err
+	}
+	return NewFakeInventory(seed), nil
+}
+
+func (f *FakeInventory) ValidateSKU(ctx context.Context, tenantID, skuID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	owner, ok := f.skus[skuID]
+	return ok && owner == tenantID, nil
+}
+
+func (f *FakeInventory) ValidateHub(ctx context.Context, tenantID, hubID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hub, ok := f.hubs[hubID]
+	return ok && hub.TenantID == tenantID, nil
+}
+
+func (f *FakeInventory) ValidateOrderLine(ctx context.Context, hubID, skuID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, skuOK := f.skus[skuID]
+	_, hubOK := f.hubs[hubID]
+	return skuOK && hubOK, nil
+}
+
+func (f *FakeInventory) ValidateOrderLines(ctx context.Context, pairs []ValidationPair) (map[ValidationPair]bool, error) {
+	results := make(map[ValidationPair]bool, len(pairs))
+	for _, pair := range pairs {
+		results[pair], _ = f.ValidateOrderLine(ctx, pair.HubID, pair.SKUID)
+	}
+	return results, nil
+}
+
+func (f *FakeInventory) CheckAndReserve(ctx context.Context, line models.Order, idempotencyKey string) (InventoryResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if result, ok := f.reservations[idempotencyKey]; ok {
+		return result, nil
+	}
+
+	result := InventoryResult{Status: "on_hold"}
+	key := stockKey{line.HubID, line.SKUID}
+	if f.stock[key] >= line.Quantity {
+		f.stock[key] -= line.Quantity
+		result = InventoryResult{Status: "new_order"}
+	}
+	if idempotencyKey != "" && result.Status == "new_order" {
+		f.reservations[idempotencyKey] = result
+	}
+	return result, nil
+}
+
+func (f *FakeInventory) Release(ctx context.Context, line models.SagaLine, idempotencyKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if idempotencyKey != "" {
+		if f.releases[idempotencyKey] {
+			return nil
+		}
+		f.releases[idempotencyKey] = true
+	}
+	f.stock[stockKey{line.HubID, line.SKUID}] += line.Quantity
+	return nil
+}
+
+func (f *FakeInventory) Availability(ctx context.Context, tenantID, skuID uuid.UUID) ([]models.HubAvailability, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var availability []models.HubAvailability
+	for _, hub := range f.hubs {
+		if hub.TenantID != tenantID {
+			continue
+		}
+		availability = append(availability, models.HubAvailability{
+			HubID:             hub.HubID,
+			AvailableQuantity: f.stock[stockKey{hub.HubID, skuID}],
+			ShippingCost:      hub.ShippingCost,
+		})
+	}
+	sort.Slice(availability, func(i, j int) bool {
+		return availability[i].HubID.String() < availability[j].HubID.String()
+	})
+	return availability, nil
+}
+
+// Stock returns the quantity of the SKU currently held at the hub.
+func (f *FakeInventory) Stock(hubID, skuID uuid.UUID) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stock[stockKey{hubID, skuID}]
+}
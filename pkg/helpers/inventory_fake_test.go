@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestFakeInventory(t *testing.T) {
+	ctx := context.Background()
+	tenant, otherTenant := uuid.New(), uuid.New()
+	sku, hub, otherHub := uuid.New(), uuid.New(), uuid.New()
+
+	fake := NewFakeInventory(FakeInventorySeed{
+		SKUs:  []FakeSKU{{TenantID: tenant, SKUID: sku}},
+		Hubs:  []FakeHub{{TenantID: tenant, HubID: hub}, {TenantID: tenant, HubID: otherHub}},
+		Stock: []FakeStock{{HubID: hub, SKUID: sku, Quantity: 5}},
+	})
+
+	if valid, _ := fake.ValidateSKU(ctx, tenant, sku); !valid {
+		t.Error("expected seeded SKU to be valid")
+	}
+	if valid, _ := fake.ValidateSKU(ctx, otherTenant, sku); valid {
+		t.Error("expected SKU of another tenant to be invalid")
+	}
+	if valid, _ := fake.ValidateHub(ctx, tenant, uuid.New()); valid {
+		t.Error("expected unknown hub to be invalid")
+	}
+
+	line := models.Order{SKUID: sku, HubID: hub, Quantity: 3}
+	if result, _ := fake.CheckAndReserve(ctx, line, "attempt-1"); result.Status != "new_order" {
+		t.Fatalf("expected reservation to succeed, got %s", result.Status)
+	}
+	// A repeated attempt gets the first answer without reserving again
+	if result, _ := fake.CheckAndReserve(ctx, line, "attempt-1"); result.Status != "new_order" || fake.Stock(hub, sku) != 2 {
+		t.Fatalf("expected repeated key to keep 2 in stock, got %s with %d", result.Status, fake.Stock(hub, sku))
+	}
+	if result, _ := fake.CheckAndReserve(ctx, line, "attempt-2"); result.Status != "on_hold" {
+		t.Fatalf("expected on_hold with 2 left, got %s", result.Status)
+	}
+	// A repeated release, e.g. by the recovery job, gives the stock back once
+	for i := 0; i < 2; i++ {
+		if err := fake.Release(ctx, models.SagaLine{SKUID: sku, HubID: hub, Quantity: 3}, "release-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := fake.Stock(hub, sku); got != 5 {
+		t.Errorf("expected stock back at 5, got %d", got)
+	}
+	// The on_hold answer is not replayed once stock is back
+	if result, _ := fake.CheckAndReserve(ctx, line, "attempt-2"); result.Status != "new_order" || fake.Stock(hub, sku) != 2 {
+		t.Fatalf("expected repeated key to reserve now, got %s with %d", result.Status, fake.Stock(hub, sku))
+	}
+
+	availability, _ := fake.Availability(ctx, tenant, sku)
+	if len(availability) != 2 {
+		t.Fatalf("expected both hubs of the tenant, got %+v", availability)
+	}
+}
+
+func TestValidateSKUAndHubsWithFakeInventory(t *testing.T) {
+	ctx := context.Background()
+	tenant, sku, hub := uuid.New(), uuid.New(), uuid.New()
+
+	original := Inventory
+	defer func() { Inventory = original }()
+	Inventory = NewFakeInventory(FakeInventorySeed{
+		SKUs: []FakeSKU{{TenantID: tenant, SKUID: sku}},
+		Hubs: []FakeHub{{TenantID: tenant, HubID: hub}},
+	})
+
+	tests := []struct {
+		name  string
+		sku   uuid.UUID
+		hub   uuid.UUID
+		valid bool
+	}{
+		{"Known SKU and hub", sku, hub, true},
+		{"Unknown SKU", uuid.New(), hub, false},
+		{"Unknown hub", sku, uuid.New(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := ValidateSKUAndHubs(ctx, tt.sku, tt.hub, tenant)
+			if err != nil || valid != tt.valid {
+				t.Errorf("expected valid=%v, got %v (err %v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/httpclient"
+	"github.com/omniful/go_commons/httpclient/request"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// HTTPInventory is the InventoryService backed by the IMS HTTP API. Every
+// call goes through the IMS circuit breaker and bulkhead.
+type HTTPInventory struct {
+	client httpclient.Client
+}
+
+func NewHTTPInventory(client httpclient.Client) *HTTPInventory {
+	return &HTTPInventory{client: client}
+}
+
+// send runs req through CallIMS and returns the status and body of the
+// response. Idempotent requests are retried.
+func (h *HTTPInventory) send(ctx context.Context, req request.Request, idempotent bool) (int, []byte, error) {
+	var status int
+	var body []byte
+	err := CallIMS(ctx, idempotent, func() error {
+		resp, err := h.client.Send(ctx, req)
+		if err != nil {
+			return err
+		}
+		status, body = resp.StatusCode(), resp.Body()
+		return imsStatusError(status)
+	})
+	return status, body, err
+}
+
+func tenantHeaders(tenantID uuid.UUID) url.Values {
+	headers := url.Values{}
+	headers.Set("X-Tenant-ID", tenantID.String())
+	return headers
+}
+
+// validate treats a 200 as valid and any other answer as invalid; failed
+// calls are returned as errors so they are not cached.
+func (h *HTTPInventory) validate(ctx context.Context, uri string, tenantID uuid.UUID) (bool, error) {
+	req, err := request.NewBuilder().
+		SetUri(uri).
+		SetMethod("GET").
+		SetHeaders(tenantHeaders(tenantID)).
+		Build()
+	if err != nil {
+		return false, err
+	}
+
+	status, _, err := h.send(ctx, req, true)
+	if err != nil {
+		return false, err
+	}
+	return status == 200, nil
+}
+
+func (h *HTTPInventory) ValidateSKU(ctx context.Context, tenantID, skuID uuid.UUID) (bool, error) {
+	return h.validate(ctx, fmt.Sprintf("/skus/%s", skuID), tenantID)
+}
+
+func (h *HTTPInventory) ValidateHub(ctx context.Context, tenantID, hubID uuid.UUID) (bool, error) {
+	return h.validate(ctx, fmt.Sprintf("/hubs/%s", hubID), tenantID)
+}
+
+func (h *HTTPInventory) ValidateOrderLine(ctx context.Context, hubID, skuID uuid.UUID) (bool, error) {
+	req, err := request.NewBuilder().
+		SetUri(fmt.Sprintf("/validators/validate_order/%s/%s", hubID, skuID)).
+		SetMethod("GET").
+		Build()
+	if err != nil {
+		return false, err
+	}
+
+	status, body, err := h.send(ctx, req, true)
+	if err != nil {
+		return false, err
+	}
+	if status != 200 {
+		return false, fmt.Errorf("order validation returned status %d", status)
+	}
+
+	var response struct {
+		IsValid bool `json:"is_valid"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return false, err
+	}
+	return response.IsValid, nil
+}
+
+func (h *HTTPInventory) ValidateOrderLines(ctx context.Context, pairs []ValidationPair) (map[ValidationPair]bool, error) {
+	req, err := request.NewBuilder().
+		SetUri("/validators/validate_orders").
+		SetMethod("POST").
+		SetBody(map[string]interface{}{"pairs": pairs}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation does not change stock, so the POST is safe to retry
+	status, body, err := h.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	if status == 404 || status == 405 {
+		return nil, ErrBulkValidationUnsupported
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("bulk validation returned status %d", status)
+	}
+
+	var response struct {
+		Results []struct {
+			HubID   uuid.UUID `json:"hub_id"`
+			SKUID   uuid.UUID `json:"sku_id"`
+			IsValid bool      `json:"is_valid"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	results := make(map[ValidationPair]bool, len(response.Results))
+	for _, r := range response.Results {
+		results[ValidationPair{HubID: r.HubID, SKUID: r.SKUID}] = r.IsValid
+	}
+	return results, nil
+}
+
+func (h *HTTPInventory) CheckAndReserve(ctx context.Context, line models.Order, idempotencyKey string) (InventoryResult, error) {
+	payload := map[string]interface{}{
+		"sku_id":   line.SKUID,
+		"hub_id":   line.HubID,
+		"quantity": line.Quantity,
+	}
+
+	headers := url.Values{}
+	headers.Set("Idempotency-Key", idempotencyKey)
+
+	req, err := request.NewBuilder().
+		SetUri("/inventory/check-and-update").
+		SetMethod("POST").
+		SetHeaders(headers).
+		SetBody(payload).
+		Build()
+	if err != nil {
+		return InventoryResult{Status: "error"}, err
+	}
+
+	// Safe to retry: the retries repeat this attempt's idempotency key, so
+	// IMS reserves once
+	_, body, err := h.send(ctx, req, true)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "HTTP call failed for order %s:"), line.OrderID)
+		return InventoryResult{Status: "error"}, err
+	}
+
+	return EvaluateInventoryResult(body), nil
+}
+
+func (h *HTTPInventory) Release(ctx context.Context, line models.SagaLine, idempotencyKey string) error {
+	payload := map[string]interface{}{
+		"sku_id":   line.SKUID,
+		"hub_id":   line.HubID,
+		"quantity": line.Quantity,
+	}
+
+	headers := url.Values{}
+	headers.Set("Idempotency-Key", idempotencyKey)
+
+	req, err := request.NewBuilder().
+		SetUri("/inventory/release").
+		SetMethod("POST").
+		SetHeaders(headers).
+		SetBody(payload).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	// Safe to retry: IMS releases once per idempotency key
+	status, _, err := h.send(ctx, req, true)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("inventory release returned status %d", status)
+	}
+	return nil
+}
+
+func (h *HTTPInventory) Availability(ctx context.Context, tenantID, skuID uuid.UUID) ([]models.HubAvailability, error) {
+	req, err := request.NewBuilder().
+		SetUri(fmt.Sprintf("/inventory/availability/%s", skuID)).
+		SetMethod("GET").
+		SetHeaders(tenantHeaders(tenantID)).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	status, body, err := h.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("availability lookup returned status %d", status)
+	}
+
+	var result struct {
+		Hubs []models.HubAvailability `json:"hubs"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return result.Hubs, nil
+}
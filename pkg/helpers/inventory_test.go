@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestInventoryIdempotencyKey(t *testing.T) {
+	orderID := uuid.New()
+	key := InventoryIdempotencyKey(orderID, 2, 0)
+
+	// A redelivered message or a retry after an error reads the same attempt
+	if got := InventoryIdempotencyKey(orderID, 2, 0); got != key {
+		t.Errorf("expected the attempt to keep key %s, got %s", key, got)
+	}
+	if release := InventoryReleaseKey(orderID, 2, 0); release == key || release != InventoryReleaseKey(orderID, 2, 0) {
+		t.Errorf("expected a stable release key distinct from the reservation, got %s", release)
+	}
+
+	tests := []struct {
+		name    string
+		ownerID uuid.UUID
+		attempt int
+		line    int
+	}{
+		{name: "Next Line", ownerID: orderID, attempt: 2, line: 1},
+		{name: "Attempt After A Release", ownerID: orderID, attempt: 3, line: 0},
+		{name: "Fulfilment Order", ownerID: uuid.New(), attempt: 2, line: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := InventoryIdempotencyKey(tc.ownerID, tc.attempt, tc.line); got == key {
+				t.Errorf("expected a new key, got %s again", got)
+			}
+		})
+	}
+}
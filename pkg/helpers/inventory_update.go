@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetOnHoldOrdersForStock returns the orders waiting for stock of the SKU at
+// the hub, highest priority then oldest first: orders for the SKU itself, kits using it as a
+// component, and split orders with a fulfilment order still held there.
+func GetOnHoldOrdersForStock(ctx context.Context, skuID, hubID uuid.UUID) ([]models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	parentIDs, err := heldFulfilmentParents(ctx, skuID, hubID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := bson.A{
+		bson.M{"hub_id": hubID, "sku_id": skuID},
+		bson.M{"hub_id": hubID, "components.sku_id": skuID},
+	}
+	if len(parentIDs) > 0 {
+		matches = append(matches, bson.M{"order_id": bson.M{"$in": parentIDs}})
+	}
+	filter := bson.M{
+		"status": bson.M{"$in": retryableStatuses},
+		"$or":    matches,
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	for cursor.Next(ctx) {
+		var order models.Order
+		if err := cursor.Decode(&order); err != nil {
+			log.Warnf(i18n.Translate(ctx, "Failed to decode order: %v"), err)
+			continue
+		}
+		orders = append(orders, order)
+	}
+	return orders, cursor.Err()
+}
+
+func heldFulfilmentParents(ctx context.Context, skuID, hubID uuid.UUID) ([]uuid.UUID, error) {
+	collection, err := getFulfilmentCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"sku_id": skuID, "hub_id": hubID, "status": "on_hold"}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var parentIDs []uuid.UUID
+	for cursor.Next(ctx) {
+		var child models.FulfilmentOrder
+		if err := cursor.Decode(&child); err != nil {
+			continue
+		}
+		if !containsUUID(parentIDs, child.ParentOrderID) {
+			parentIDs = append(parentIDs, child.ParentOrderID)
+		}
+	}
+	return parentIDs, cursor.Err()
+}
+
+// ReleaseOrdersForStock retries the orders waiting for the SKU at the hub
+// after IMS reports a stock change, in the order of each tenant's allocation
+// policy. Each order goes through retry, normally RetryHeldOrder, so the
+// attempt counts against its retry schedule. It returns how many orders were
+// retried.
+func ReleaseOrdersForStock(ctx context.Context, update models.InventoryUpdate, retry func(context.Context, models.Order) models.Order) (int, error) {
+	if update.AvailableQuantity <= 0 {
+		return 0, nil
+	}
+
+	orders, err := GetOnHoldOrdersForStock(ctx, update.SKUID, update.HubID)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infof(i18n.Translate(ctx, "Stock update for sku %s at hub %s: %d held orders"), update.SKUID, update.HubID, len(orders))
+	return AllocateHeldOrders(ctx, orders, RealAllocationPolicyStore{}, retry), nil
+}
@@ -0,0 +1,281 @@
+package helpers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omniful/go_commons/config"
+)
+
+// KafkaConfig holds the Kafka cluster and topic settings under `kafka` in
+// config.yaml. Every setting can be overridden by an environment variable
+// named after its key, e.g. KAFKA_SASL_PASSWORD for `kafka.sasl.password`;
+// KAFKA_BROKERS takes a comma-separated list.
+type KafkaConfig struct {
+	Brokers          []string
+	Version          string
+	Encoding         string // json or protobuf, for the messages OMS publishes
+	ProducerClientID string
+	ConsumerClientID string
+	ConsumerGroup    string
+	DedupeTTL        time.Duration // how long consumed order messages are remembered to skip redeliveries
+	Async            KafkaAsync
+	Topics           KafkaTopics
+	DLQ              KafkaDLQ
+	SASL             KafkaSASL
+	TLS              KafkaTLS
+}
+
+type KafkaTopics struct {
+	// OrderCreated carries new orders from intake to the inventory check.
+	OrderCreated string
+	// OrderEvents carries the order domain events for other services.
+	OrderEvents string
+	// InventoryUpdated carries stock changes published by IMS.
+	InventoryUpdated string
+	// ValidationInvalidated carries IMS catalog changes that make cached
+	// SKU/hub validation results stale.
+	ValidationInvalidated string
+}
+
+// KafkaAsync configures batching of produced messages in the background
+// (see AsyncProducer). With it disabled each message is sent on its own.
+type KafkaAsync struct {
+	Enabled    bool
+	Linger     time.Duration
+	BatchSize  int
+	BufferSize int
+}
+
+// KafkaDLQ configures retries of failed messages and where they go after.
+type KafkaDLQ struct {
+	MaxAttempts  int           // attempts before a message is dead-lettered
+	RetryBackoff time.Duration // wait after the first failed attempt, doubling after each
+	TopicSuffix  string
+}
+
+// Topic is the dead-letter topic of a source topic, e.g. order.created.dlq.
+func (d KafkaDLQ) Topic(source string) string {
+	return source + d.TopicSuffix
+}
+
+type KafkaSASL struct {
+	Enabled   bool
+	Mechanism string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+	Username  string
+	Password  string
+}
+
+type KafkaTLS struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+var (
+	kafkaSASLMechanisms = map[string]bool{"PLAIN": true, "SCRAM-SHA-256": true, "SCRAM-SHA-512": true}
+	kafkaContentTypes   = map[string]string{"json": ContentTypeJSON, "protobuf": ContentTypeProtobuf}
+)
+
+// LoadKafkaConfig reads the Kafka settings, falling back to the local
+// docker-compose cluster for anything left unset. An environment override
+// that does not parse is an error naming the variable.
+func LoadKafkaConfig(ctx context.Context) (KafkaConfig, error) {
+	settings := &kafkaSettings{ctx: ctx}
+	cfg := KafkaConfig{
+		Brokers:          settings.list("kafka.brokers"),
+		Version:          settings.string("kafka.version", "3.4.0"),
+		Encoding:         strings.ToLower(settings.string("kafka.encoding", "json")),
+		ProducerClientID: settings.string("kafka.producer.client_id", "my-producer"),
+		ConsumerClientID: settings.string("kafka.consumer.client_id", "my-consumer"),
+		ConsumerGroup:    settings.string("kafka.consumer.group", "my-consumer-group"),
+		DedupeTTL:        settings.duration("kafka.consumer.dedupe_ttl", 24*time.Hour),
+		Async: KafkaAsync{
+			Enabled:    settings.bool("kafka.producer.async.enabled"),
+			Linger:     settings.duration("kafka.producer.async.linger", 10*time.Millisecond),
+			BatchSize:  settings.int("kafka.producer.async.batch_size", 100),
+			BufferSize: settings.int("kafka.producer.async.buffer_size", 10000),
+		},
+		Topics: KafkaTopics{
+			OrderCreated:          settings.string("kafka.topics.order_created", "order.created"),
+			OrderEvents:           settings.string("kafka.topics.order_events", "order.events"),
+			InventoryUpdated:      settings.string("kafka.topics.inventory_updated", "inventory.updated"),
+			ValidationInvalidated: settings.string("kafka.topics.validation_invalidated", "ims.validation.invalidated"),
+		},
+		DLQ: KafkaDLQ{
+			MaxAttempts:  settings.int("kafka.dlq.max_attempts", 3),
+			RetryBackoff: settings.duration("kafka.dlq.retry_backoff", time.Second),
+			TopicSuffix:  settings.string("kafka.dlq.topic_suffix", ".dlq"),
+		},
+		SASL: KafkaSASL{
+			Enabled:   settings.bool("kafka.sasl.enabled"),
+			Mechanism: strings.ToUpper(settings.string("kafka.sasl.mechanism", "SCRAM-SHA-512")),
+			Username:  settings.string("kafka.sasl.username", ""),
+			Password:  settings.string("kafka.sasl.password", ""),
+		},
+		TLS: KafkaTLS{
+			Enabled:            settings.bool("kafka.tls.enabled"),
+			CAFile:             settings.string("kafka.tls.ca_file", ""),
+			CertFile:           settings.string("kafka.tls.cert_file", ""),
+			KeyFile:            settings.string("kafka.tls.key_file", ""),
+			InsecureSkipVerify: settings.bool("kafka.tls.insecure_skip_verify"),
+		},
+	}
+	if settings.err != nil {
+		return cfg, settings.err
+	}
+	if len(cfg.Brokers) == 0 {
+		cfg.Brokers = []string{"localhost:9092"}
+	}
+
+	if _, ok := kafkaContentTypes[cfg.Encoding]; !ok {
+		return cfg, fmt.Errorf("unsupported kafka.encoding %q, want json or protobuf", cfg.Encoding)
+	}
+	if cfg.SASL.Enabled {
+		if !kafkaSASLMechanisms[cfg.SASL.Mechanism] {
+			return cfg, fmt.Errorf("unsupported kafka.sasl.mechanism %q", cfg.SASL.Mechanism)
+		}
+		if cfg.SASL.Username == "" || cfg.SASL.Password == "" {
+			return cfg, errors.New("kafka.sasl.username and kafka.sasl.password are required with SASL enabled")
+		}
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		return cfg, errors.New("kafka.tls.cert_file and kafka.tls.key_file must be set together")
+	}
+	return cfg, nil
+}
+
+// ContentType is the content-type header of the messages OMS publishes.
+func (c KafkaConfig) ContentType() string {
+	return kafkaContentTypes[c.Encoding]
+}
+
+// TLSConfig builds the client TLS settings, or nil with TLS disabled.
+func (t KafkaTLS) TLSConfig() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in kafka CA file %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// kafkaEnvName is the environment variable overriding a config key.
+func kafkaEnvName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// kafkaEnv returns the environment override of a config key, if set.
+func kafkaEnv(key string) (string, bool) {
+	return os.LookupEnv(kafkaEnvName(key))
+}
+
+// kafkaSettings reads settings from config.yaml and their environment
+// overrides, collecting the overrides that do not parse.
+type kafkaSettings struct {
+	ctx context.Context
+	err error
+}
+
+// invalid records an environment override that does not parse.
+func (s *kafkaSettings) invalid(key, value string, err error) {
+	s.err = errors.Join(s.err, fmt.Errorf("invalid %s %q: %w", kafkaEnvName(key), value, err))
+}
+
+func (s *kafkaSettings) string(key, fallback string) string {
+	value, ok := kafkaEnv(key)
+	if !ok {
+		value = config.GetString(s.ctx, key)
+	}
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func (s *kafkaSettings) bool(key string) bool {
+	env, ok := kafkaEnv(key)
+	if !ok {
+		return config.GetBool(s.ctx, key)
+	}
+	enabled, err := strconv.ParseBool(env)
+	if err != nil {
+		s.invalid(key, env, err)
+	}
+	return enabled
+}
+
+func (s *kafkaSettings) int(key string, fallback int) int {
+	value := config.GetInt(s.ctx, key)
+	if env, ok := kafkaEnv(key); ok {
+		var err error
+		if value, err = strconv.Atoi(env); err != nil {
+			s.invalid(key, env, err)
+		}
+	}
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *kafkaSettings) duration(key string, fallback time.Duration) time.Duration {
+	value := config.GetDuration(s.ctx, key)
+	if env, ok := kafkaEnv(key); ok {
+		var err error
+		if value, err = time.ParseDuration(env); err != nil {
+			s.invalid(key, env, err)
+		}
+	}
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (s *kafkaSettings) list(key string) []string {
+	value, ok := kafkaEnv(key)
+	if !ok {
+		return config.GetStringSlice(s.ctx, key)
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
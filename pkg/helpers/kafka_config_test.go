@@ -0,0 +1,149 @@
+package helpers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadKafkaConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantErr     bool
+		wantErrVar  string
+		wantBrokers []string
+		wantType    string
+		wantAsync   KafkaAsync
+		wantDedupe  time.Duration
+		wantTopic   string
+		wantSASL    KafkaSASL
+	}{
+		{
+			name:        "Defaults",
+			wantBrokers: []string{"localhost:9092"},
+			wantType:    ContentTypeJSON,
+			wantAsync:   KafkaAsync{Linger: 10 * time.Millisecond, BatchSize: 100, BufferSize: 10000},
+			wantDedupe:  24 * time.Hour,
+			wantTopic:   "order.created",
+			wantSASL:    KafkaSASL{Mechanism: "SCRAM-SHA-512"},
+		},
+		{
+			name: "Environment Overrides",
+			env: map[string]string{
+				"KAFKA_BROKERS":                    "b-1.kafka:9096, b-2.kafka:9096",
+				"KAFKA_ENCODING":                   "Protobuf",
+				"KAFKA_PRODUCER_ASYNC_ENABLED":     "true",
+				"KAFKA_PRODUCER_ASYNC_LINGER":      "50ms",
+				"KAFKA_PRODUCER_ASYNC_BATCH_SIZE":  "20",
+				"KAFKA_PRODUCER_ASYNC_BUFFER_SIZE": "500",
+				"KAFKA_CONSUMER_DEDUPE_TTL":        "2h",
+				"KAFKA_TOPICS_ORDER_CREATED":       "staging.order.created",
+				"KAFKA_SASL_ENABLED":               "true",
+				"KAFKA_SASL_MECHANISM":             "scram-sha-256",
+				"KAFKA_SASL_USERNAME":              "oms",
+				"KAFKA_SASL_PASSWORD":              "secret",
+			},
+			wantBrokers: []string{"b-1.kafka:9096", "b-2.kafka:9096"},
+			wantType:    ContentTypeProtobuf,
+			wantAsync:   KafkaAsync{Enabled: true, Linger: 50 * time.Millisecond, BatchSize: 20, BufferSize: 500},
+			wantDedupe:  2 * time.Hour,
+			wantTopic:   "staging.order.created",
+			wantSASL:    KafkaSASL{Enabled: true, Mechanism: "SCRAM-SHA-256", Username: "oms", Password: "secret"},
+		},
+		{
+			name:    "Unsupported Encoding",
+			env:     map[string]string{"KAFKA_ENCODING": "avro"},
+			wantErr: true,
+		},
+		{
+			name:    "SASL Without Credentials",
+			env:     map[string]string{"KAFKA_SASL_ENABLED": "true"},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported SASL Mechanism",
+			env:     map[string]string{"KAFKA_SASL_ENABLED": "true", "KAFKA_SASL_MECHANISM": "GSSAPI", "KAFKA_SASL_USERNAME": "oms", "KAFKA_SASL_PASSWORD": "secret"},
+			wantErr: true,
+		},
+		{
+			name:       "Unparsable Integer",
+			env:        map[string]string{"KAFKA_PRODUCER_ASYNC_BUFFER_SIZE": "10k"},
+			wantErr:    true,
+			wantErrVar: "KAFKA_PRODUCER_ASYNC_BUFFER_SIZE",
+		},
+		{
+			name:       "Unparsable Duration",
+			env:        map[string]string{"KAFKA_CONSUMER_DEDUPE_TTL": "24"},
+			wantErr:    true,
+			wantErrVar: "KAFKA_CONSUMER_DEDUPE_TTL",
+		},
+		{
+			name:       "Unparsable Boolean",
+			env:        map[string]string{"KAFKA_TLS_ENABLED": "yes"},
+			wantErr:    true,
+			wantErrVar: "KAFKA_TLS_ENABLED",
+		},
+		{
+			name:    "Client Certificate Without Key",
+			env:     map[string]string{"KAFKA_TLS_CERT_FILE": "/etc/kafka/client.pem"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := LoadKafkaConfig(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				if !strings.Contains(err.Error(), tc.wantErrVar) {
+					t.Errorf("expected the error to name %s, got %v", tc.wantErrVar, err)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.Brokers, tc.wantBrokers) {
+				t.Errorf("expected brokers %v, got %v", tc.wantBrokers, cfg.Brokers)
+			}
+			if cfg.Async != tc.wantAsync {
+				t.Errorf("expected async %+v, got %+v", tc.wantAsync, cfg.Async)
+			}
+			if cfg.DedupeTTL != tc.wantDedupe {
+				t.Errorf("expected dedupe TTL %v, got %v", tc.wantDedupe, cfg.DedupeTTL)
+			}
+			if cfg.ContentType() != tc.wantType {
+				t.Errorf("expected content type %s, got %s", tc.wantType, cfg.ContentType())
+			}
+			if cfg.Topics.OrderCreated != tc.wantTopic {
+				t.Errorf("expected topic %s, got %s", tc.wantTopic, cfg.Topics.OrderCreated)
+			}
+			if cfg.SASL != tc.wantSASL {
+				t.Errorf("expected SASL %+v, got %+v", tc.wantSASL, cfg.SASL)
+			}
+		})
+	}
+}
+
+func TestKafkaTLSConfig(t *testing.T) {
+	disabled, err := KafkaTLS{}.TLSConfig()
+	if err != nil || disabled != nil {
+		t.Errorf("expected no TLS config when disabled, got %v, %v", disabled, err)
+	}
+
+	if _, err := (KafkaTLS{Enabled: true, CAFile: "/does/not/exist.pem"}).TLSConfig(); err == nil {
+		t.Errorf("expected an error for a missing CA file")
+	}
+
+	enabled, err := KafkaTLS{Enabled: true}.TLSConfig()
+	if err != nil || enabled == nil {
+		t.Errorf("expected a TLS config using the system CAs, got %v, %v", enabled, err)
+	}
+}
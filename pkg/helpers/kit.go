@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type KitStore interface {
+	Save(ctx context.Context, kit models.Kit) error
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.Kit, error)
+}
+
+type RealKitStore struct{}
+
+func (RealKitStore) Save(ctx context.Context, kit models.Kit) error {
+	return SaveKit(ctx, kit)
+}
+
+func (RealKitStore) List(ctx context.Context, tenantID uuid.UUID) ([]models.Kit, error) {
+	return GetKits(ctx, tenantID)
+}
+
+// KitExpander attaches component lines to orders for kit SKUs.
+type KitExpander interface {
+	Expand(ctx context.Context, order models.Order) (models.Order, error)
+}
+
+type RealKitExpander struct{}
+
+func (RealKitExpander) Expand(ctx context.Context, order models.Order) (models.Order, error) {
+	return ExpandKitOrder(ctx, order)
+}
+
+func SaveKit(ctx context.Context, kit models.Kit) error {
+	collection, err := getCollection(ctx, "mongo.kitCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": kit.TenantID, "kit_sku_id": kit.KitSKUID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": kit}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save kit:"))
+	}
+	return err
+}
+
+func GetKits(ctx context.Context, tenantID uuid.UUID) ([]models.Kit, error) {
+	collection, err := getCollection(ctx, "mongo.kitCollectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"tenant_id": tenantID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var kits []models.Kit
+	if err := cursor.All(ctx, &kits); err != nil {
+		return nil, err
+	}
+	return kits, nil
+}
+
+// GetKit returns the kit definition for a SKU, or false when the SKU is not a kit.
+func GetKit(ctx context.Context, tenantID, skuID uuid.UUID) (models.Kit, bool, error) {
+	collection, err := getCollection(ctx, "mongo.kitCollectionName")
+	if err != nil {
+		return models.Kit{}, false, err
+	}
+
+	var kit models.Kit
+	err = collection.FindOne(ctx, bson.M{"tenant_id": tenantID, "kit_sku_id": skuID}).Decode(&kit)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Kit{}, false, nil
+	}
+	if err != nil {
+		return models.Kit{}, false, err
+	}
+	return kit, true, nil
+}
+
+// ExpandKitOrder attaches the component lines of the order's SKU when it is a kit.
+func ExpandKitOrder(ctx context.Context, order models.Order) (models.Order, error) {
+	kit, ok, err := GetKit(ctx, order.TenantID, order.SKUID)
+	if err != nil || !ok {
+		return order, err
+	}
+	return ExpandKit(order, kit), nil
+}
+
+// ExpandKit sets the order's component lines from the kit definition. The
+// order keeps the kit SKU so tenants still see what they ordered.
+func ExpandKit(order models.Order, kit models.Kit) models.Order {
+	components := make([]models.OrderComponent, 0, len(kit.Components))
+	for _, component := range kit.Components {
+		components = append(components, models.OrderComponent{
+			SKUID:          component.SKUID,
+			QuantityPerKit: component.Quantity,
+			Quantity:       component.Quantity * order.Quantity,
+		})
+	}
+	order.Components = components
+	return order
+}
+
+// ComponentOrders splits a kit order into one order per component line for the
+// inventory check. A non-kit order is returned as is.
+func ComponentOrders(order models.Order) []models.Order {
+	if len(order.Components) == 0 {
+		return []models.Order{order}
+	}
+
+	lines := make([]models.Order, 0, len(order.Components))
+	for _, component := range order.Components {
+		line := order
+		line.SKUID = component.SKUID
+		line.Quantity = component.QuantityPerKit * order.Quantity
+		line.Components = nil
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// KitAvailability converts per-component stock into the number of whole kits
+// each hub can assemble. Hubs missing any component are left out.
+func KitAvailability(components []models.OrderComponent, perComponent [][]models.HubAvailability) []models.HubAvailability {
+	kits := make(map[uuid.UUID]int)
+	seen := make(map[uuid.UUID]int)
+	var order []uuid.UUID
+
+	for i, component := range components {
+		for _, hub := range perComponent[i] {
+			possible := hub.AvailableQuantity / component.QuantityPerKit
+			if _, ok := seen[hub.HubID]; !ok {
+				order = append(order, hub.HubID)
+				kits[hub.HubID] = possible
+			} else if possible < kits[hub.HubID] {
+				kits[hub.HubID] = possible
+			}
+			seen[hub.HubID]++
+		}
+	}
+
+	var result []models.HubAvailability
+	for _, hubID := range order {
+		if seen[hubID] == len(components) {
+			result = append(result, models.HubAvailability{HubID: hubID, AvailableQuantity: kits[hubID]})
+		}
+	}
+	return result
+}
+
+// fetchKitAvailability reports how many kits each hub can assemble.
+func fetchKitAvailability(ctx context.Context, order models.Order, inventory InventoryService) ([]models.HubAvailability, error) {
+	lines := ComponentOrders(order)
+	perComponent := make([][]models.HubAvailability, 0, len(lines))
+	for _, line := range lines {
+		availability, err := fetchSKUAvailability(ctx, line, inventory)
+		if err != nil {
+			return nil, err
+		}
+		perComponent = append(perComponent, availability)
+	}
+	return KitAvailability(order.Components, perComponent), nil
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestExpandKitAndComponentOrders(t *testing.T) {
+	kitSKU := uuid.New()
+	partA := uuid.New()
+	partB := uuid.New()
+
+	kit := models.Kit{
+		KitSKUID: kitSKU,
+		Components: []models.KitComponent{
+			{SKUID: partA, Quantity: 1},
+			{SKUID: partB, Quantity: 3},
+		},
+	}
+	order := ExpandKit(models.Order{SKUID: kitSKU, Quantity: 2}, kit)
+
+	if order.SKUID != kitSKU {
+		t.Errorf("expected kit SKU to stay on the order, got %s", order.SKUID)
+	}
+	if len(order.Components) != 2 || order.Components[1].Quantity != 6 {
+		t.Fatalf("unexpected components: %+v", order.Components)
+	}
+
+	// A split child for one kit only needs one kit's worth of components
+	order.Quantity = 1
+	lines := ComponentOrders(order)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 component lines, got %d", len(lines))
+	}
+	if lines[0].SKUID != partA || lines[0].Quantity != 1 || lines[1].SKUID != partB || lines[1].Quantity != 3 {
+		t.Errorf("unexpected component lines: %+v", lines)
+	}
+
+	plain := models.Order{SKUID: partA, Quantity: 4}
+	if lines := ComponentOrders(plain); len(lines) != 1 || lines[0].SKUID != partA {
+		t.Errorf("expected plain order to be a single line, got %+v", lines)
+	}
+}
+
+func TestKitAvailability(t *testing.T) {
+	hub1 := uuid.New()
+	hub2 := uuid.New()
+	hub3 := uuid.New()
+
+	components := []models.OrderComponent{
+		{SKUID: uuid.New(), QuantityPerKit: 1},
+		{SKUID: uuid.New(), QuantityPerKit: 2},
+	}
+	perComponent := [][]models.HubAvailability{
+		{{HubID: hub1, AvailableQuantity: 10}, {HubID: hub2, AvailableQuantity: 3}, {HubID: hub3, AvailableQuantity: 5}},
+		{{HubID: hub1, AvailableQuantity: 7}, {HubID: hub2, AvailableQuantity: 20}},
+	}
+
+	result := KitAvailability(components, perComponent)
+	expected := map[uuid.UUID]int{hub1: 3, hub2: 3}
+	if len(result) != len(expected) {
+		t.Fatalf("expected %d hubs, got %+v", len(expected), result)
+	}
+	for _, hub := range result {
+		if hub.AvailableQuantity != expected[hub.HubID] {
+			t.Errorf("hub %s: expected %d kits, got %d", hub.HubID, expected[hub.HubID], hub.AvailableQuantity)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrStaleLeader is returned by Fence when a newer leader has taken over.
+var ErrStaleLeader = errors.New("lease is held by a newer leader")
+
+// LeaseStore holds the leases in Redis. The compare operations check that
+// the key still holds value and change it in one atomic step, so a lease
+// that expired and was taken by another replica is never touched.
+type LeaseStore interface {
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	Incr(ctx context.Context, key string) (int64, error)
+	// CompareAndExpire resets the key's TTL if it holds value
+	CompareAndExpire(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// CompareAndDelete deletes the key if it holds value
+	CompareAndDelete(ctx context.Context, key, value string) (bool, error)
+}
+
+// LeaderElector holds a Redis lease so that a singleton job runs on one
+// replica at a time. Each lease comes with a fencing token that grows with
+// every new leader, so a replica that lost its lease without noticing can
+// be told apart from the current leader.
+type LeaderElector struct {
+	store  LeaseStore
+	job    string
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	token      int64
+	leaseUntil time.Time
+}
+
+// NewLeaderElector competes for the lease of job, identified as holder
+// (ReplicaID() if empty). The lease expires ttl after its last renewal.
+func NewLeaderElector(store LeaseStore, job, holder string, ttl time.Duration) *LeaderElector {
+	if holder == "" {
+		holder = ReplicaID()
+	}
+	return &LeaderElector{store: store, job: job, holder: holder, ttl: ttl, now: time.Now}
+}
+
+var (
+	replicaOnce sync.Once
+	replicaID   string
+)
+
+// ReplicaID identifies this OMS process among its replicas.
+func ReplicaID() string {
+	replicaOnce.Do(func() {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "oms"
+		}
+		replicaID = fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+	})
+	return replicaID
+}
+
+func (l *LeaderElector) leaseKey() string {
+	return "oms:leader:" + l.job
+}
+
+func (l *LeaderElector) tokenKey() string {
+	return "oms:leader:" + l.job + ":token"
+}
+
+func (l *LeaderElector) leaseValue(token int64) string {
+	return l.holder + "|" + strconv.FormatInt(token, 10)
+}
+
+// TryAcquire renews the lease if this replica holds it, or takes it if it
+// is free. It reports whether this replica is the leader afterwards.
+func (l *LeaderElector) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := l.now()
+
+	// Renewing an expired lease could overwrite a successor's, so it has to
+	// be won again instead
+	if l.token != 0 && !start.Before(l.leaseUntil) {
+		log.Warnf(i18n.Translate(ctx, "Lease of %s expired before renewal (token %d)"), l.job, l.token)
+		l.token = 0
+	}
+
+	if l.token != 0 {
+		renewed, err := l.store.CompareAndExpire(ctx, l.leaseKey(), l.leaseValue(l.token), l.ttl)
+		if err != nil {
+			return l.leaseValidLocked(start), err
+		}
+		if renewed {
+			l.leaseUntil = start.Add(l.ttl)
+			return true, nil
+		}
+		log.Warnf(i18n.Translate(ctx, "Lost leadership of %s (token %d)"), l.job, l.token)
+		l.token = 0
+	}
+
+	// Tokens only ever grow, so a new leader always fences out older ones
+	token, err := l.store.Incr(ctx, l.tokenKey())
+	if err != nil {
+		return false, err
+	}
+	acquired, err := l.store.SetNX(ctx, l.leaseKey(), l.leaseValue(token), l.ttl)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	l.token = token
+	l.leaseUntil = start.Add(l.ttl)
+	log.Infof(i18n.Translate(ctx, "%s became leader of %s with token %d"), l.holder, l.job, token)
+	return true, nil
+}
+
+// IsLeader reports whether this replica holds an unexpired lease. A leader
+// that cannot reach Redis stops counting itself as leader once its lease
+// would have expired, before another replica can take over.
+func (l *LeaderElector) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.leaseValidLocked(l.now())
+}
+
+func (l *LeaderElector) leaseValidLocked(now time.Time) bool {
+	return l.token != 0 && now.Before(l.leaseUntil)
+}
+
+// Token is the fencing token of the current lease, or 0 if not leader.
+func (l *LeaderElector) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.leaseValidLocked(l.now()) {
+		return 0
+	}
+	return l.token
+}
+
+// Fence records the lease's token for the job in Mongo and fails with
+// ErrStaleLeader if a newer leader has already recorded a higher one. Jobs
+// call it before each run, and through CheckFence before each batch, so a
+// paused or partitioned replica whose lease expired cannot run after its
+// successor.
+func (l *LeaderElector) Fence(ctx context.Context) error {
+	token := l.Token()
+	if token == 0 {
+		return ErrStaleLeader
+	}
+
+	collection, err := getCollection(ctx, "mongo.jobLeaseCollectionName")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"job": l.job},
+		bson.M{"$setOnInsert": bson.M{"job": l.job, "token": int64(0)}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"job": l.job, "token": bson.M{"$lte": token}},
+		bson.M{"$set": bson.M{"token": token, "holder": l.holder, "fenced_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrStaleLeader
+	}
+	return nil
+}
+
+type fenceKey struct{}
+
+// WithFence attaches the fencing check of the job running under ctx. Jobs
+// that write in batches call CheckFence before each one, so a leader that
+// lost its lease half way through a run stops writing once its successor
+// has fenced it out.
+func WithFence(ctx context.Context, fence func(context.Context) error) context.Context {
+	return context.WithValue(ctx, fenceKey{}, fence)
+}
+
+// CheckFence runs the fencing check attached to ctx, if any, and fails with
+// ErrStaleLeader once a newer leader has taken over.
+func CheckFence(ctx context.Context) error {
+	fence, ok := ctx.Value(fenceKey{}).(func(context.Context) error)
+	if !ok {
+		return nil
+	}
+	return fence(ctx)
+}
+
+// Run keeps competing for and renewing the lease every renewEvery until ctx
+// is done, then gives the lease up so another replica can take over at once.
+func (l *LeaderElector) Run(ctx context.Context, renewEvery time.Duration) {
+	ticker := time.NewTicker(renewEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Release(context.Background())
+			return
+		case <-ticker.C:
+			if _, err := l.TryAcquire(ctx); err != nil {
+				log.Warnf(i18n.Translate(ctx, "Leader election for %s failed: %v"), l.job, err)
+			}
+		}
+	}
+}
+
+// Release gives up the lease if this replica still holds it.
+func (l *LeaderElector) Release(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.token == 0 {
+		return
+	}
+	if _, err := l.store.CompareAndDelete(ctx, l.leaseKey(), l.leaseValue(l.token)); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to release leadership of %s: %v"), l.job, err)
+	}
+	l.token = 0
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryLeaseStore struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	values  map[string]string
+	expires map[string]time.Time
+	counter map[string]int64
+}
+
+func newMemoryLeaseStore(now func() time.Time) *memoryLeaseStore {
+	return &memoryLeaseStore{now: now, values: map[string]string{}, expires: map[string]time.Time{}, counter: map[string]int64{}}
+}
+
+func (m *memoryLeaseStore) live(key string) (string, bool) {
+	value, ok := m.values[key]
+	if ok && !m.now().Before(m.expires[key]) {
+		delete(m.values, key)
+		return "", false
+	}
+	return value, ok
+}
+
+func (m *memoryLeaseStore) CompareAndExpire(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.live(key); !ok || current != value {
+		return false, nil
+	}
+	m.expires[key] = m.now().Add(ttl)
+	return true, nil
+}
+
+func (m *memoryLeaseStore) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.live(key); !ok || current != value {
+		return false, nil
+	}
+	delete(m.values, key)
+	return true, nil
+}
+
+func (m *memoryLeaseStore) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.live(key); ok {
+		return false, nil
+	}
+	m.values[key] = value.(string)
+	m.expires[key] = m.now().Add(ttl)
+	return true, nil
+}
+
+func (m *memoryLeaseStore) Incr(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counter[key]++
+	return m.counter[key], nil
+}
+
+func TestLeaderElectionFailover(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	now := func() time.Time { return clock }
+	store := newMemoryLeaseStore(now)
+
+	first := NewLeaderElector(store, "order-retry", "replica-a", 15*time.Second)
+	second := NewLeaderElector(store, "order-retry", "replica-b", 15*time.Second)
+	first.now, second.now = now, now
+
+	if ok, err := first.TryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("expected first replica to lead, got %v, %v", ok, err)
+	}
+	if ok, _ := second.TryAcquire(ctx); ok {
+		t.Fatal("expected second replica to be refused while the lease is held")
+	}
+	firstToken := first.Token()
+
+	// Renewals keep the lease past its original expiry
+	clock = clock.Add(10 * time.Second)
+	if ok, _ := first.TryAcquire(ctx); !ok {
+		t.Fatal("expected leader to renew its lease")
+	}
+	clock = clock.Add(10 * time.Second)
+	if !first.IsLeader() {
+		t.Fatal("expected renewed lease to still be valid")
+	}
+	if ok, _ := second.TryAcquire(ctx); ok {
+		t.Fatal("expected renewed lease to keep the second replica out")
+	}
+
+	// The leader stops renewing; the lease expires and the other replica takes over
+	clock = clock.Add(16 * time.Second)
+	if first.IsLeader() {
+		t.Fatal("expected leader to step down once its lease expired")
+	}
+	if ok, _ := second.TryAcquire(ctx); !ok {
+		t.Fatal("expected second replica to take over the expired lease")
+	}
+	if second.Token() <= firstToken {
+		t.Errorf("expected fencing token to grow, got %d after %d", second.Token(), firstToken)
+	}
+
+	// The former leader cannot renew its way back in
+	if ok, _ := first.TryAcquire(ctx); ok {
+		t.Fatal("expected former leader to be refused")
+	}
+
+	// Releasing hands the lease over immediately
+	second.Release(ctx)
+	if ok, _ := first.TryAcquire(ctx); !ok {
+		t.Fatal("expected lease to be free after release")
+	}
+}
+
+func TestLeaderRenewalKeepsSuccessorLease(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	now := func() time.Time { return clock }
+	store := newMemoryLeaseStore(now)
+
+	first := NewLeaderElector(store, "order-retry", "replica-a", 15*time.Second)
+	second := NewLeaderElector(store, "order-retry", "replica-b", 15*time.Second)
+	first.now, second.now = now, now
+
+	if ok, _ := first.TryAcquire(ctx); !ok {
+		t.Fatal("expected first replica to lead")
+	}
+
+	// Redis expires the lease before the first replica's own clock says so,
+	// and the second replica takes it
+	store.mu.Lock()
+	store.expires["oms:leader:order-retry"] = clock
+	store.mu.Unlock()
+	if ok, _ := second.TryAcquire(ctx); !ok {
+		t.Fatal("expected second replica to take the expired lease")
+	}
+
+	if ok, _ := first.TryAcquire(ctx); ok {
+		t.Fatal("expected the former leader's renewal to fail")
+	}
+	first.Release(ctx)
+
+	clock = clock.Add(10 * time.Second)
+	if ok, _ := second.TryAcquire(ctx); !ok || !second.IsLeader() {
+		t.Fatal("expected the successor's lease to survive the former leader's renewal and release")
+	}
+}
+
+func TestCheckFence(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{"No Fence", context.Background(), nil},
+		{"Current Leader", WithFence(context.Background(), func(context.Context) error { return nil }), nil},
+		{"Stale Leader", WithFence(context.Background(), func(context.Context) error { return ErrStaleLeader }), ErrStaleLeader},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := CheckFence(tc.ctx); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -3,17 +3,17 @@ package helpers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"net/url"
+	"errors"
 	"time"
 
 	"github.com/aditya-goyal-omniful/oms/pkg/database"
 	"github.com/aditya-goyal-omniful/oms/pkg/models"
 	"github.com/google/uuid"
-	"github.com/omniful/go_commons/httpclient"
-	"github.com/omniful/go_commons/httpclient/request"
+	"github.com/omniful/go_commons/config"
 	"github.com/omniful/go_commons/i18n"
 	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -37,65 +37,114 @@ func (RealValidator) Validate(ctx context.Context, skuID, hubID, tenantID uuid.U
 	return ValidateSKUAndHubs(ctx, skuID, hubID, tenantID)
 }
 
-var client httpclient.Client
+func EvaluateInventoryResult(body []byte) InventoryResult {
+	var result struct {
+		Available           bool       `json:"available"`
+		ExpectedAvailableAt *time.Time `json:"expected_available_at"`
+	}
 
-func InitHTTPClient() {
-	client = httpclient.New("http://localhost:8087")
-}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return InventoryResult{Status: "error"}
+	}
 
-func SendInventoryCheckRequest(ctx context.Context, order models.Order, httpClient httpclient.Client) ([]byte, error) {
-	payload := map[string]interface{}{
-		"sku_id":   order.SKUID,
-		"hub_id":   order.HubID,
-		"quantity": order.Quantity,
+	if result.Available {
+		return InventoryResult{Status: "new_order"}
 	}
+	return InventoryResult{Status: "on_hold", ExpectedAvailableAt: result.ExpectedAvailableAt}
+}
 
-	req, _ := request.NewBuilder().
-		SetUri("/inventory/check-and-update").
-		SetMethod("POST").
-		SetBody(payload).
-		Build()
+func EvaluateInventoryResponse(body []byte) string {
+	return EvaluateInventoryResult(body).Status
+}
 
-	resp, err := httpClient.Send(ctx, req)
+// CheckOrder reserves the order's quantity in its current reservation
+// attempt.
+func CheckOrder(ctx context.Context, order models.Order, inventory InventoryService) string {
+	result, err := inventory.CheckAndReserve(ctx, order, InventoryIdempotencyKey(order.OrderID, order.ReservationAttempt, 0))
 	if err != nil {
-		log.WithError(err).Error(i18n.Translate(ctx, "HTTP call failed for order %s:"), order.OrderID)
-		return nil, err
+		return "error"
 	}
+	return result.Status
+}
 
-	return resp.Body(), nil
+// getCollection resolves a collection in the OMS database from its config key.
+func getCollection(ctx context.Context, key string) (*mongo.Collection, error) {
+	dbname := config.GetString(ctx, "mongo.dbname")
+	return database.GetMongoCollection(dbname, config.GetString(ctx, key))
 }
 
-func EvaluateInventoryResponse(body []byte) string {
-	var result struct {
-		Available bool `json:"available"`
+// EnsureOrderSaved inserts the order if it is not stored yet. Orders created
+// through the API only reach Mongo through the Kafka consumer.
+func EnsureOrderSaved(ctx context.Context, order models.Order) error {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
 	}
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "error"
+	filter := bson.M{"order_id": order.OrderID}
+	update := bson.M{"$setOnInsert": order}
+	result, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save order %s:"), order.OrderID)
+		return err
+	}
+	if result.UpsertedCount > 0 {
+		EmitOrderEvent(ctx, models.EventOrderCreated, order, "")
 	}
+	return nil
+}
 
-	if result.Available {
-		return "new_order"
+func UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status string) error {
+	collection, err := database.GetMongoCollection("oms", "orders")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"order_id": orderID}
+	update := bson.M{"$set": bson.M{"status": status}}
+
+	_, err = collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "MongoDB update failed:"))
 	}
-	return "on_hold"
+	return err
 }
 
-func CheckOrder(ctx context.Context, order models.Order, httpClient httpclient.Client) string {
-	body, err := SendInventoryCheckRequest(ctx, order, httpClient)
+// ErrOrderStatusChanged is returned when an order moved on from the status a
+// worker read it in before the worker could update it.
+var ErrOrderStatusChanged = errors.New("order status changed concurrently")
+
+// TransitionOrderStatus moves the order to status only if it is still in the
+// status it was read with, so the inventory.updated consumer, the retry
+// poller and the admin retry cannot all allocate the same held order.
+func TransitionOrderStatus(ctx context.Context, order models.Order, status string) error {
+	collection, err := database.GetMongoCollection("oms", "orders")
 	if err != nil {
-		return "error"
+		return err
+	}
+
+	filter := bson.M{"order_id": order.OrderID, "tenant_id": order.TenantID, "status": order.Status}
+	update := bson.M{"$set": bson.M{"status": status}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "MongoDB update failed:"))
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrOrderStatusChanged
 	}
-	return EvaluateInventoryResponse(body)
+	return nil
 }
 
-func UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status string) error {
+func MarkOrderSplit(ctx context.Context, orderID uuid.UUID, status string) error {
 	collection, err := database.GetMongoCollection("oms", "orders")
 	if err != nil {
 		return err
 	}
 
 	filter := bson.M{"order_id": orderID}
-	update := bson.M{"$set": bson.M{"status": status}}
+	update := bson.M{"$set": bson.M{"status": status, "is_split": true}}
 
 	_, err = collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -104,37 +153,131 @@ func UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status string) er
 	return err
 }
 
-func CheckAndUpdateOrder(ctx context.Context, order models.Order) {
-	newStatus := CheckOrder(ctx, order, client)
+// CheckAndUpdateOrder runs the inventory check for the order, splitting it
+// across hubs when the requested hub cannot cover it, and returns the order
+// with its updated status and fulfilment orders. Pre-orders are left alone
+// until their SKU launches. A status change is published as
+// order.status_changed.
+func CheckAndUpdateOrder(ctx context.Context, order models.Order) models.Order {
+	updated, _ := checkAndUpdateOrderStatus(ctx, order)
+	return updated
+}
+
+// checkAndUpdateOrderStatus is CheckAndUpdateOrder that returns
+// ErrOrderStatusChanged, with the order as it was read, when another worker
+// moved the order on first.
+func checkAndUpdateOrderStatus(ctx context.Context, order models.Order) (models.Order, error) {
+	previous := order.Status
+	updated, err := checkAndUpdateOrder(ctx, order)
+	if err != nil {
+		return updated, err
+	}
+	emitStatusChange(ctx, updated, previous)
+	return updated, nil
+}
+
+func checkAndUpdateOrder(ctx context.Context, order models.Order) (models.Order, error) {
+	if order.Status == "pre_order" {
+		return order, nil
+	}
+
+	if order.IsSplit {
+		return updateSplitOrder(ctx, order), nil
+	}
+
+	if config.GetBool(ctx, "allocation.nearest_hub_enabled") {
+		order = AllocateNearestHub(ctx, order)
+	}
+
+	result, saga := ReserveInventory(ctx, order, uuid.Nil, Inventory)
+	newStatus := result.Status
 	if newStatus == "error" {
-		return
+		order.LastError = "inventory check failed"
+		return order, nil
 	}
 
-	if err := UpdateOrderStatus(ctx, uuid.UUID(order.OrderID), newStatus); err != nil {
-		log.WithError(err).Error(i18n.Translate(ctx, "Failed to update status for order %s:"), order.OrderID)
+	if newStatus == "on_hold" && config.GetBool(ctx, "fulfilment.split_enabled") {
+		children, err := SplitOrder(ctx, order, Inventory)
+		if err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Failed to split order %s:"), order.OrderID)
+		} else if len(children) > 0 {
+			order.IsSplit = true
+			order.FulfilmentOrders = children
+			return updateSplitOrder(ctx, order), nil
+		}
 	}
-}
 
-func GetOnHoldOrders(ctx context.Context) ([]models.Order, error) {
-	var orders []models.Order
+	if newStatus == "on_hold" {
+		if expected, ok := ResolveBackorder(ctx, order, result); ok {
+			if err := UpdateOrderBackorder(ctx, order.OrderID, *expected); err != nil {
+				log.WithError(err).Error(i18n.Translate(ctx, "Failed to backorder order %s:"), order.OrderID)
+				order.LastError = err.Error()
+				return order, nil
+			}
+			order.Status = "backordered"
+			order.ExpectedAvailableAt = expected
+			return order, nil
+		}
+	}
 
-	cursor, err := database.Collection.Find(ctx, bson.M{"status": "on_hold"})
+	err := TransitionOrderStatus(ctx, order, newStatus)
+	if errors.Is(err, ErrOrderStatusChanged) {
+		// Another worker allocated or cancelled the order first
+		log.Infof(i18n.Translate(ctx, "Order %s changed status during its inventory check, releasing its reservation"), order.OrderID)
+		if saga != nil {
+			if err := releaseLostSaga(ctx, saga, Inventory, err.Error()); err != nil {
+				log.WithError(err).Error(i18n.Translate(ctx, "Failed to release inventory for order %s:"), order.OrderID)
+			}
+		}
+		return order, err
+	}
 	if err != nil {
-		return nil, err
+		if saga != nil {
+			if err := ReleaseSaga(ctx, saga, Inventory, err.Error()); err != nil {
+				log.WithError(err).Error(i18n.Translate(ctx, "Failed to release inventory for order %s:"), order.OrderID)
+			}
+		}
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to update status for order %s:"), order.OrderID)
+		order.LastError = err.Error()
+		return order, nil
 	}
-	defer cursor.Close(ctx)
+	if saga != nil {
+		if err := ConfirmSaga(ctx, saga); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Failed to confirm inventory saga for order %s:"), order.OrderID)
+		}
+	}
+	order.Status = newStatus
+	return order, nil
+}
 
-	for cursor.Next(ctx) {
-		var order models.Order
-		if err := cursor.Decode(&order); err != nil {
-			continue
+func updateSplitOrder(ctx context.Context, order models.Order) models.Order {
+	children := order.FulfilmentOrders
+	if children == nil {
+		var err error
+		children, err = RetryFulfilmentOrders(ctx, order, Inventory)
+		if err != nil {
+			log.WithError(err).Error(i18n.Translate(ctx, "Failed to retry fulfilment orders for order %s:"), order.OrderID)
+			order.LastError = err.Error()
+			return order
 		}
-		orders = append(orders, order)
 	}
 
-	return orders, nil
+	status := AggregateSplitStatus(children)
+	if err := MarkOrderSplit(ctx, order.OrderID, status); err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to update status for order %s:"), order.OrderID)
+		order.LastError = err.Error()
+		return order
+	}
+
+	order.IsSplit = true
+	order.Status = status
+	order.FulfilmentOrders = children
+	return order
 }
 
+// retryableStatuses are the statuses of orders still waiting for stock.
+var retryableStatuses = []string{"on_hold", "partially_allocated", "backordered"}
+
 func FetchOrders(ctx context.Context, sellerID uuid.UUID, status string, startDate, endDate time.Time) ([]models.Order, error) {
 	filter := bson.M{}
 
@@ -182,43 +325,29 @@ func FetchOrders(ctx context.Context, sellerID uuid.UUID, status string, startDa
 }
 
 func ValidateSKUAndHubs(ctx context.Context, skuID, hubID, tenantID uuid.UUID) (bool, error) {
-	// Set up headers with tenant ID
-	headers := url.Values{}
-	headers.Set("X-Tenant-ID", tenantID.String())
-
 	// Validate SKU
-	skuReq, err := request.NewBuilder().
-		SetUri(fmt.Sprintf("/skus/%s", skuID)).
-		SetMethod("GET").
-		SetHeaders(headers).
-		Build()
-	if err != nil {
-		log.Warnf(i18n.Translate(ctx, "Failed to build SKU request: %v"), err)
+	skuValid, err := CachedValidation(ctx, tenantID, "sku", func() (bool, error) {
+		return Inventory.ValidateSKU(ctx, tenantID, skuID)
+	}, skuID)
+	if IsIMSUnavailable(err) {
 		return false, err
 	}
-
-	skuResp, err := client.Send(ctx, skuReq)
-	if err != nil || skuResp.StatusCode() != 200 {
+	if err != nil || !skuValid {
 		log.Warnf(i18n.Translate(ctx, "SKU validation failed: %v"), err)
 		return false, nil
 	}
 
 	// Validate Hub
-	hubReq, err := request.NewBuilder().
-		SetUri(fmt.Sprintf("/hubs/%s", hubID)).
-		SetMethod("GET").
-		SetHeaders(headers).
-		Build()
-	if err != nil {
-		log.Warnf(i18n.Translate(ctx, "Failed to build Hub request: %v"), err)
+	hubValid, err := CachedValidation(ctx, tenantID, "hub", func() (bool, error) {
+		return Inventory.ValidateHub(ctx, tenantID, hubID)
+	}, hubID)
+	if IsIMSUnavailable(err) {
 		return false, err
 	}
-
-	hubResp, err := client.Send(ctx, hubReq)
-	if err != nil || hubResp.StatusCode() != 200 {
+	if err != nil || !hubValid {
 		log.Warnf(i18n.Translate(ctx, "Hub validation failed: %v"), err)
 		return false, nil
 	}
 
 	return true, nil
-}
\ No newline at end of file
+}
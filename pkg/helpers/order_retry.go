@@ -0,0 +1,439 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Held orders in these states give up after the maximum number of attempts.
+// Backorders have a promised date and keep retrying at the maximum delay.
+var failableStatuses = []string{"on_hold", "partially_allocated"}
+
+// OrderBackoff configures how long the retry worker waits before trying a
+// held order again.
+type OrderBackoff struct {
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Multiplier float64
+	// Jitter is the fraction of the delay randomised either way.
+	Jitter float64
+	// MaxAttempts is the number of retries before an order fails; 0 retries
+	// forever.
+	MaxAttempts int
+}
+
+// LoadOrderBackoff reads the backoff from `retry.backoff.*` and
+// `retry.max_attempts`.
+func LoadOrderBackoff(ctx context.Context) OrderBackoff {
+	backoff := OrderBackoff{
+		BaseDelay:   config.GetDuration(ctx, "retry.backoff.base_delay"),
+		MaxDelay:    config.GetDuration(ctx, "retry.backoff.max_delay"),
+		Multiplier:  config.GetFloat64(ctx, "retry.backoff.multiplier"),
+		Jitter:      config.GetFloat64(ctx, "retry.backoff.jitter"),
+		MaxAttempts: config.GetInt(ctx, "retry.max_attempts"),
+	}
+	if backoff.BaseDelay <= 0 {
+		backoff.BaseDelay = 5 * time.Minute
+	}
+	if backoff.MaxDelay < backoff.BaseDelay {
+		backoff.MaxDelay = 6 * time.Hour
+	}
+	if backoff.Multiplier < 1 {
+		backoff.Multiplier = 2
+	}
+	if backoff.Jitter < 0 || backoff.Jitter > 1 {
+		backoff.Jitter = 0.2
+	}
+	return backoff
+}
+
+// Delay is the wait after the given attempt (1 for the first retry), with
+// random in [0, 1) spreading it by the jitter fraction.
+func (b OrderBackoff) Delay(attempt int, random float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(b.BaseDelay) * math.Pow(b.Multiplier, float64(attempt-1))
+	if delay > float64(b.MaxDelay) {
+		delay = float64(b.MaxDelay)
+	}
+	delay *= 1 - b.Jitter + 2*b.Jitter*random
+	return time.Duration(delay)
+}
+
+// ScheduleRetry records a failed attempt on the order: it bumps the retry
+// count and either sets the next retry time or, once the attempts run out,
+// moves the order to `failed`.
+func ScheduleRetry(order models.Order, attemptErr string, backoff OrderBackoff, now time.Time, random float64) models.Order {
+	order.RetryCount++
+	order.LastAttemptAt = &now
+	order.LastError = attemptErr
+
+	if backoff.MaxAttempts > 0 && order.RetryCount >= backoff.MaxAttempts && containsString(failableStatuses, order.Status) {
+		order.Status = "failed"
+		order.NextRetryAt = nil
+		return order
+	}
+
+	next := now.Add(backoff.Delay(order.RetryCount, random))
+	order.NextRetryAt = &next
+	return order
+}
+
+// retryStateTimeout bounds the writes that record a retry. They run on their
+// own deadline, since the attempt may have used up the order's.
+const retryStateTimeout = 5 * time.Second
+
+// DeferRetry records an attempt that got no answer about stock, e.g. while
+// IMS is down or its circuit breaker is open. The order backs off as if
+// retried again but keeps its attempts, so an outage cannot fail it.
+func DeferRetry(order models.Order, attemptErr string, backoff OrderBackoff, now time.Time, random float64) models.Order {
+	order.LastAttemptAt = &now
+	order.LastError = attemptErr
+
+	next := now.Add(backoff.Delay(order.RetryCount+1, random))
+	order.NextRetryAt = &next
+	return order
+}
+
+// RetryHeldOrder runs the inventory check for a held order and records the
+// attempt. Orders still waiting for stock are scheduled for a later retry
+// and fail once they run out of attempts; checks that failed without an
+// answer about stock are retried later without using up an attempt. An
+// order another worker moved on first is returned as it was read.
+func RetryHeldOrder(ctx context.Context, order models.Order, backoff OrderBackoff) models.Order {
+	before := order
+	order.LastError = ""
+	updated, err := checkAndUpdateOrderStatus(ctx, order)
+	if errors.Is(err, ErrOrderStatusChanged) {
+		return before
+	}
+	now := time.Now()
+
+	// Record the attempt even when the inventory check hit the deadline
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), retryStateTimeout)
+	defer cancel()
+
+	if !containsString(retryableStatuses, updated.Status) {
+		updated.LastAttemptAt = &now
+		updated.NextRetryAt = nil
+		updated.LastError = ""
+		if err := saveRetryState(ctx, updated); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Failed to record retry of order %s:"), updated.OrderID)
+		}
+		return updated
+	}
+
+	if updated.LastError != "" {
+		updated = DeferRetry(updated, updated.LastError, backoff, now, rand.Float64())
+		if err := saveRetryState(ctx, updated); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Failed to record retry of order %s:"), updated.OrderID)
+			return updated
+		}
+		emitRetryUpdate(ctx, before, updated)
+		return updated
+	}
+
+	attemptErr := "insufficient stock"
+	held := updated.Status
+	updated = ScheduleRetry(updated, attemptErr, backoff, now, rand.Float64())
+
+	if updated.Status == "failed" {
+		if err := FailOrder(ctx, updated); err != nil {
+			log.WithError(err).Error(i18n.Translate(ctx, "Failed to mark order %s as failed:"), updated.OrderID)
+			return updated
+		}
+		emitStatusChange(ctx, updated, held)
+		return updated
+	}
+
+	if err := saveRetryState(ctx, updated); err != nil {
+		log.WithError(err).Warn(i18n.Translate(ctx, "Failed to record retry of order %s:"), updated.OrderID)
+		return updated
+	}
+	emitRetryUpdate(ctx, before, updated)
+	return updated
+}
+
+// RetryChangedFields lists the fields of a held order that a failed retry
+// changed for consumers of order events. The retry schedule (retry_count,
+// last_attempt_at, next_retry_at) moves on every attempt and is left out.
+func RetryChangedFields(before, after models.Order) []string {
+	if before.LastError != after.LastError {
+		return []string{"last_error"}
+	}
+	return nil
+}
+
+// emitRetryUpdate publishes order.updated only when the retry changed
+// something consumers care about.
+func emitRetryUpdate(ctx context.Context, before, after models.Order) {
+	if changed := RetryChangedFields(before, after); len(changed) > 0 {
+		EmitOrderEvent(ctx, models.EventOrderUpdated, after, "", changed...)
+	}
+}
+
+func saveRetryState(ctx context.Context, order models.Order) error {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{"retry_count": order.RetryCount, "last_attempt_at": order.LastAttemptAt}
+	unset := bson.M{}
+	if order.NextRetryAt != nil {
+		set["next_retry_at"] = order.NextRetryAt
+	} else {
+		unset["next_retry_at"] = ""
+	}
+	if order.LastError != "" {
+		set["last_error"] = order.LastError
+	} else {
+		unset["last_error"] = ""
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"order_id": order.OrderID}, update)
+	return err
+}
+
+// FailOrder moves an order that ran out of retries to the terminal `failed`
+// state and releases any stock its fulfilment orders had reserved.
+func FailOrder(ctx context.Context, order models.Order) error {
+	if err := ReleaseOrderInventory(ctx, order.OrderID, "retries exhausted"); err != nil {
+		// The recovery job retries sagas left in the releasing state
+		log.WithError(err).Warn(i18n.Translate(ctx, "Inventory release incomplete for failed order %s:"), order.OrderID)
+	}
+
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          "failed",
+			"retry_count":     order.RetryCount,
+			"last_attempt_at": order.LastAttemptAt,
+			"last_error":      order.LastError,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{"next_retry_at": ""},
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"order_id": order.OrderID}, update); err != nil {
+		return err
+	}
+
+	if order.IsSplit {
+		fulfilments, err := getFulfilmentCollection(ctx)
+		if err != nil {
+			return err
+		}
+		update := bson.M{"$set": bson.M{"status": "failed", "updated_at": time.Now()}}
+		if _, err := fulfilments.UpdateMany(ctx, bson.M{"parent_order_id": order.OrderID}, update); err != nil {
+			return err
+		}
+	}
+
+	log.Warnf(i18n.Translate(ctx, "Order %s failed after %d attempts: %s"), order.OrderID, order.RetryCount, order.LastError)
+	return nil
+}
+
+// EnsureRetryIndex creates the indexes the retry worker uses to find held
+// orders that are due and to stream them in priority order.
+func EnsureRetryIndex(ctx context.Context) error {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_retry_at", Value: 1}},
+			Options: options.Index().SetName("status_next_retry_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "priority", Value: -1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("status_priority_created_at"),
+		},
+	})
+	return err
+}
+
+// StreamOrdersDueForRetry passes the held orders whose next retry is due,
+// including those never retried yet, to fn in pages of pageSize, highest
+// priority then oldest first. Orders are read from a cursor as fn consumes
+// them. It stops at the first error from fn or when ctx is done.
+func StreamOrdersDueForRetry(ctx context.Context, now time.Time, pageSize int, fn func([]models.Order) error) error {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"status": bson.M{"$in": retryableStatuses},
+		"$or": bson.A{
+			bson.M{"next_retry_at": nil},
+			bson.M{"next_retry_at": bson.M{"$lte": now}},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}).
+		SetBatchSize(int32(pageSize))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	page := make([]models.Order, 0, pageSize)
+	for cursor.Next(ctx) {
+		var order models.Order
+		if err := cursor.Decode(&order); err != nil {
+			log.Warnf(i18n.Translate(ctx, "Failed to decode order: %v"), err)
+			continue
+		}
+		page = append(page, order)
+		if len(page) == pageSize {
+			if err := fn(page); err != nil {
+				return err
+			}
+			page = make([]models.Order, 0, pageSize)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(page) > 0 {
+		return fn(page)
+	}
+	return nil
+}
+
+// ErrOrderNotRetryable is returned when an order is neither waiting for
+// stock nor failed.
+var ErrOrderNotRetryable = errors.New("order is not waiting for stock")
+
+// IsWaitingForStock reports whether orders in status are retried.
+func IsWaitingForStock(status string) bool {
+	return containsString(retryableStatuses, status)
+}
+
+// GetOrder returns the order with the given ID.
+func GetOrder(ctx context.Context, orderID uuid.UUID) (models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	var order models.Order
+	err = collection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Order{}, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// ReopenFailedOrder puts a failed order back on hold with a fresh attempt
+// count so it can be retried again. Its fulfilment orders, whose stock was
+// released when it failed, are put back on hold too.
+func ReopenFailedOrder(ctx context.Context, order models.Order) (models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return order, err
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"status": "on_hold", "retry_count": 0, "updated_at": time.Now()},
+		"$unset": bson.M{"next_retry_at": "", "last_error": ""},
+	}
+	result, err := collection.UpdateOne(ctx, bson.M{"order_id": order.OrderID, "status": "failed"}, update)
+	if err != nil {
+		return order, err
+	}
+	if result.MatchedCount == 0 {
+		return order, ErrOrderNotRetryable
+	}
+
+	if order.IsSplit {
+		fulfilments, err := getFulfilmentCollection(ctx)
+		if err != nil {
+			return order, err
+		}
+		update := bson.M{"$set": bson.M{"status": "on_hold", "updated_at": time.Now()}}
+		if _, err := fulfilments.UpdateMany(ctx, bson.M{"parent_order_id": order.OrderID}, update); err != nil {
+			return order, err
+		}
+	}
+
+	order.Status = "on_hold"
+	order.RetryCount = 0
+	order.NextRetryAt = nil
+	order.LastError = ""
+	emitStatusChange(ctx, order, "failed")
+	return order, nil
+}
+
+// FindOrdersForRetry returns the held or failed orders matching the filter,
+// whether or not their next retry is due, highest priority then oldest
+// first.
+func FindOrdersForRetry(ctx context.Context, filter models.RetryFilter) ([]models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"status": bson.M{"$in": append([]string{"failed"}, retryableStatuses...)}}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.TenantID != uuid.Nil {
+		query["tenant_id"] = filter.TenantID
+	}
+	if filter.SellerID != uuid.Nil {
+		query["seller_id"] = filter.SellerID
+	}
+	if filter.SKUID != uuid.Nil {
+		query["$or"] = bson.A{bson.M{"sku_id": filter.SKUID}, bson.M{"components.sku_id": filter.SKUID}}
+	}
+	if filter.HubID != uuid.Nil {
+		query["hub_id"] = filter.HubID
+	}
+	if filter.CreatedBefore != nil {
+		query["created_at"] = bson.M{"$lt": *filter.CreatedBefore}
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
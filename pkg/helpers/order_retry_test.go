@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+)
+
+func TestOrderBackoffDelay(t *testing.T) {
+	backoff := OrderBackoff{BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Multiplier: 2, Jitter: 0.2}
+
+	tests := []struct {
+		name    string
+		attempt int
+		random  float64
+		want    time.Duration
+	}{
+		{"First Attempt No Jitter", 1, 0.5, time.Minute},
+		{"Doubles", 3, 0.5, 4 * time.Minute},
+		{"Capped", 10, 0.5, 10 * time.Minute},
+		{"Lowest Jitter", 2, 0, 96 * time.Second},
+		{"Highest Jitter", 2, 1, 144 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoff.Delay(tt.attempt, tt.random); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestScheduleRetry(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	backoff := OrderBackoff{BaseDelay: time.Minute, MaxDelay: time.Hour, Multiplier: 2, MaxAttempts: 3}
+
+	tests := []struct {
+		name       string
+		order      models.Order
+		wantStatus string
+		wantNext   time.Duration
+	}{
+		{"First Retry", models.Order{Status: "on_hold"}, "on_hold", time.Minute},
+		{"Backs Off", models.Order{Status: "on_hold", RetryCount: 1}, "on_hold", 2 * time.Minute},
+		{"Out Of Attempts", models.Order{Status: "on_hold", RetryCount: 2}, "failed", 0},
+		{"Partially Allocated Fails", models.Order{Status: "partially_allocated", RetryCount: 2}, "failed", 0},
+		{"Backorder Keeps Retrying", models.Order{Status: "backordered", RetryCount: 9}, "backordered", time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScheduleRetry(tt.order, "insufficient stock", backoff, now, 0.5)
+
+			if got.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, got.Status)
+			}
+			if got.RetryCount != tt.order.RetryCount+1 || got.LastError != "insufficient stock" || !got.LastAttemptAt.Equal(now) {
+				t.Errorf("attempt not recorded: %+v", got)
+			}
+			if tt.wantNext == 0 {
+				if got.NextRetryAt != nil {
+					t.Errorf("expected no next retry, got %s", got.NextRetryAt)
+				}
+				return
+			}
+			if got.NextRetryAt == nil || !got.NextRetryAt.Equal(now.Add(tt.wantNext)) {
+				t.Errorf("expected next retry at %s, got %v", now.Add(tt.wantNext), got.NextRetryAt)
+			}
+		})
+	}
+}
+
+func TestDeferRetry(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	backoff := OrderBackoff{BaseDelay: time.Minute, MaxDelay: time.Hour, Multiplier: 2, MaxAttempts: 3}
+	order := models.Order{Status: "on_hold", RetryCount: 2}
+
+	got := DeferRetry(order, "inventory check failed", backoff, now, 0.5)
+
+	if got.Status != "on_hold" || got.RetryCount != 2 {
+		t.Errorf("expected the order held with its attempts kept, got %s after %d", got.Status, got.RetryCount)
+	}
+	if got.LastError != "inventory check failed" || !got.LastAttemptAt.Equal(now) {
+		t.Errorf("attempt not recorded: %+v", got)
+	}
+	if got.NextRetryAt == nil || !got.NextRetryAt.Equal(now.Add(4*time.Minute)) {
+		t.Errorf("expected next retry at %s, got %v", now.Add(4*time.Minute), got.NextRetryAt)
+	}
+}
+
+func TestRetryChangedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   int
+	}{
+		{"Still Out Of Stock", "insufficient stock", "insufficient stock", 0},
+		{"First Failure", "", "insufficient stock", 1},
+		{"IMS Down", "insufficient stock", "inventory check failed", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := models.Order{Status: "on_hold", LastError: tt.before, RetryCount: 1}
+			after := models.Order{Status: "on_hold", LastError: tt.after, RetryCount: 2}
+			if got := RetryChangedFields(before, after); len(got) != tt.want {
+				t.Errorf("expected %d changed fields, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrPreorderCapReached = errors.New("pre-order cap reached for sku")
+
+// PreorderGate decides the intake status of a new order and gives back the
+// launch cap of a pre-order that was not taken after all.
+type PreorderGate interface {
+	Admit(ctx context.Context, order models.Order) (string, error)
+	Release(ctx context.Context, order models.Order) error
+}
+
+type RealPreorderGate struct{}
+
+func (RealPreorderGate) Admit(ctx context.Context, order models.Order) (string, error) {
+	return AdmitOrder(ctx, order)
+}
+
+func (RealPreorderGate) Release(ctx context.Context, order models.Order) error {
+	return ReleasePreorder(ctx, order)
+}
+
+type SKULaunchStore interface {
+	Save(ctx context.Context, launch models.SKULaunch) error
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.SKULaunch, error)
+}
+
+type RealSKULaunchStore struct{}
+
+func (RealSKULaunchStore) Save(ctx context.Context, launch models.SKULaunch) error {
+	return SaveSKULaunch(ctx, launch)
+}
+
+func (RealSKULaunchStore) List(ctx context.Context, tenantID uuid.UUID) ([]models.SKULaunch, error) {
+	return GetSKULaunches(ctx, tenantID)
+}
+
+// SaveSKULaunch creates or reschedules a launch. The running pre-order count
+// is preserved across updates.
+func SaveSKULaunch(ctx context.Context, launch models.SKULaunch) error {
+	collection, err := getCollection(ctx, "mongo.skuLaunchCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": launch.TenantID, "sku_id": launch.SKUID}
+	update := bson.M{
+		"$set": bson.M{
+			"launch_at":    launch.LaunchAt,
+			"preorder_cap": launch.PreorderCap,
+			"released":     launch.Released,
+			"updated_at":   launch.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"preorder_count": 0},
+	}
+	_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save SKU launch:"))
+	}
+	return err
+}
+
+func GetSKULaunches(ctx context.Context, tenantID uuid.UUID) ([]models.SKULaunch, error) {
+	collection, err := getCollection(ctx, "mongo.skuLaunchCollectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"tenant_id": tenantID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var launches []models.SKULaunch
+	if err := cursor.All(ctx, &launches); err != nil {
+		return nil, err
+	}
+	return launches, nil
+}
+
+// AdmitOrder returns pre_order when the order's SKU has an upcoming launch and
+// reserves the order's quantity against the launch cap; otherwise on_hold.
+func AdmitOrder(ctx context.Context, order models.Order) (string, error) {
+	collection, err := getCollection(ctx, "mongo.skuLaunchCollectionName")
+	if err != nil {
+		return "", err
+	}
+
+	upcoming := bson.M{
+		"tenant_id": order.TenantID,
+		"sku_id":    order.SKUID,
+		"launch_at": bson.M{"$gt": time.Now()},
+	}
+
+	var launch models.SKULaunch
+	err = collection.FindOne(ctx, upcoming).Decode(&launch)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "on_hold", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{
+		"tenant_id": order.TenantID,
+		"sku_id":    order.SKUID,
+		"launch_at": bson.M{"$gt": time.Now()},
+		"$or": []bson.M{
+			{"preorder_cap": bson.M{"$lte": 0}},
+			{"$expr": bson.M{"$lte": []interface{}{
+				bson.M{"$add": []interface{}{"$preorder_count", order.Quantity}},
+				"$preorder_cap",
+			}}},
+		},
+	}
+	update := bson.M{"$inc": bson.M{"preorder_count": order.Quantity}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+	if result.ModifiedCount == 0 {
+		return "", ErrPreorderCapReached
+	}
+
+	log.Infof(i18n.Translate(ctx, "Order %s accepted as pre-order for SKU %s launching at %s"), order.OrderID, order.SKUID, launch.LaunchAt)
+	return "pre_order", nil
+}
+
+// ReleasePreorder takes the order's quantity back off its launch's pre-order
+// count, for pre-orders that failed to be queued or were cancelled.
+func ReleasePreorder(ctx context.Context, order models.Order) error {
+	collection, err := getCollection(ctx, "mongo.skuLaunchCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"tenant_id":      order.TenantID,
+		"sku_id":         order.SKUID,
+		"preorder_count": bson.M{"$gte": order.Quantity},
+	}
+	update := bson.M{"$inc": bson.M{"preorder_count": -order.Quantity}}
+
+	_, err = collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to release pre-order %s:"), order.OrderID)
+	}
+	return err
+}
+
+// GetDueLaunches returns launches whose date has passed but whose pre-orders
+// have not been released yet.
+func GetDueLaunches(ctx context.Context, now time.Time) ([]models.SKULaunch, error) {
+	collection, err := getCollection(ctx, "mongo.skuLaunchCollectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"launch_at": bson.M{"$lte": now}, "released": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var launches []models.SKULaunch
+	if err := cursor.All(ctx, &launches); err != nil {
+		return nil, err
+	}
+	return launches, nil
+}
+
+// GetPreorders returns the pre-orders for a launch, oldest first.
+func GetPreorders(ctx context.Context, launch models.SKULaunch) ([]models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"tenant_id": launch.TenantID, "sku_id": launch.SKUID, "status": "pre_order"}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func MarkLaunchReleased(ctx context.Context, launch models.SKULaunch) error {
+	collection, err := getCollection(ctx, "mongo.skuLaunchCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": launch.TenantID, "sku_id": launch.SKUID}
+	update := bson.M{"$set": bson.M{"released": true, "updated_at": time.Now()}}
+	_, err = collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// ReleasePreorders moves the pre-orders of a launched SKU through the normal
+// inventory check, oldest first.
+func ReleasePreorders(ctx context.Context, launch models.SKULaunch) error {
+	orders, err := GetPreorders(ctx, launch)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if err := UpdateOrderStatus(ctx, order.OrderID, "on_hold"); err != nil {
+			return err
+		}
+		previous := order.Status
+		order.Status = "on_hold"
+		emitStatusChange(ctx, order, previous)
+		CheckAndUpdateOrder(ctx, order)
+	}
+
+	log.Infof(i18n.Translate(ctx, "Released %d pre-orders for SKU %s"), len(orders), launch.SKUID)
+	return MarkLaunchReleased(ctx, launch)
+}
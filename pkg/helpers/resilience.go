@@ -0,0 +1,248 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitOpen  = errors.New("circuit breaker is open")
+	ErrBulkheadFull = errors.New("too many concurrent calls")
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// BreakerSettings configures when a CircuitBreaker trips and how it recovers.
+type BreakerSettings struct {
+	FailureThreshold int           // consecutive failures that open the breaker
+	OpenTimeout      time.Duration // how long the breaker stays open before probing
+	HalfOpenMaxCalls int           // probe calls allowed while half-open
+	SuccessThreshold int           // probe successes needed to close again
+}
+
+// BreakerSnapshot is the state of a breaker as reported by the health endpoint.
+type BreakerSnapshot struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+}
+
+// CircuitBreaker fails calls fast once a dependency keeps failing. After
+// OpenTimeout a few probe calls are let through; enough successes close it,
+// any failure opens it again.
+type CircuitBreaker struct {
+	name     string
+	settings BreakerSettings
+	now      func() time.Time
+
+	mu                sync.Mutex
+	state             BreakerState
+	failures          int
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+	openedAt          time.Time
+}
+
+func NewCircuitBreaker(name string, settings BreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenMaxCalls <= 0 {
+		settings.HalfOpenMaxCalls = 1
+	}
+	if settings.SuccessThreshold <= 0 {
+		settings.SuccessThreshold = 1
+	}
+	return &CircuitBreaker{name: name, settings: settings, now: time.Now, state: BreakerClosed}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by exactly one Success, Failure or Abandon.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if b.now().Sub(b.openedAt) < b.settings.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.halfOpenInFlight = 0
+		b.halfOpenSuccesses = 0
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.halfOpenInFlight >= b.settings.HalfOpenMaxCalls {
+			return ErrCircuitOpen
+		}
+		b.halfOpenInFlight++
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state != BreakerHalfOpen {
+		return
+	}
+	if b.halfOpenInFlight > 0 {
+		b.halfOpenInFlight--
+	}
+	b.halfOpenSuccesses++
+	if b.halfOpenSuccesses >= b.settings.SuccessThreshold {
+		b.state = BreakerClosed
+	}
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.settings.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Abandon gives back a call allowed by Allow that never reached the
+// dependency, without counting it either way.
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.halfOpenInFlight > 0 {
+		b.halfOpenInFlight--
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	return b.Snapshot().State
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{Name: b.name, State: b.state, ConsecutiveFailures: b.failures}
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		// Reported as half-open even though the transition happens on the next call
+		snapshot.State = BreakerHalfOpen
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.settings.OpenTimeout)
+		snapshot.OpenedAt = &openedAt
+		snapshot.RetryAt = &retryAt
+	}
+	return snapshot
+}
+
+// Bulkhead caps the number of calls in flight to a dependency so that a slow
+// dependency cannot tie up every request goroutine.
+type Bulkhead struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+func NewBulkhead(maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &Bulkhead{slots: make(chan struct{}, maxConcurrent), maxWait: maxWait}
+}
+
+// Acquire waits up to maxWait for a free slot. Callers must Release it.
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if b.maxWait <= 0 {
+		return ErrBulkheadFull
+	}
+
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bulkhead) Release() {
+	<-b.slots
+}
+
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
+
+func (b *Bulkhead) Capacity() int {
+	return cap(b.slots)
+}
+
+// RetrySettings configures RetryWithJitter.
+type RetrySettings struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// RetryWithJitter calls fn until it succeeds, the attempts run out or the
+// context is done, sleeping a random "full jitter" backoff between attempts.
+// Errors for which retryable returns false are returned immediately.
+func RetryWithJitter(ctx context.Context, settings RetrySettings, retryable func(error) bool, fn func() error) error {
+	attempts := settings.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = fn(); err == nil || !retryable(err) || attempt == attempts-1 {
+			return err
+		}
+
+		select {
+		case <-time.After(jitteredBackoff(settings.BaseDelay, settings.MaxDelay, attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func jitteredBackoff(base, max time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	backoff := base << attempt
+	if max > 0 && (backoff > max || backoff <= 0) {
+		backoff = max
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
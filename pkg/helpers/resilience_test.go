@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker("ims", BreakerSettings{FailureThreshold: 2, OpenTimeout: 10 * time.Second})
+	breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected closed breaker to allow call %d, got %v", i, err)
+		}
+		breaker.Failure()
+	}
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected open after 2 failures, got %s", breaker.State())
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	// After the timeout a single probe is let through
+	now = now.Add(11 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected second probe to be rejected, got %v", err)
+	}
+
+	// A failed probe opens the breaker again
+	breaker.Failure()
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected open after failed probe, got %s", breaker.State())
+	}
+
+	now = now.Add(11 * time.Second)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	breaker.Success()
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected closed after successful probe, got %s", breaker.State())
+	}
+}
+
+func TestBulkhead(t *testing.T) {
+	bulkhead := NewBulkhead(1, 10*time.Millisecond)
+	ctx := context.Background()
+
+	if err := bulkhead.Acquire(ctx); err != nil {
+		t.Fatalf("expected first acquire to succeed, got %v", err)
+	}
+	if err := bulkhead.Acquire(ctx); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("expected ErrBulkheadFull, got %v", err)
+	}
+	bulkhead.Release()
+	if err := bulkhead.Acquire(ctx); err != nil {
+		t.Fatalf("expected acquire after release to succeed, got %v", err)
+	}
+}
+
+func TestRetryWithJitter(t *testing.T) {
+	settings := RetrySettings{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	failure := errors.New("boom")
+
+	tests := []struct {
+		name      string
+		failures  int
+		retryable bool
+		wantCalls int
+		wantErr   bool
+	}{
+		{"Succeeds first time", 0, true, 1, false},
+		{"Succeeds after retries", 2, true, 3, false},
+		{"Gives up after max attempts", 5, true, 3, true},
+		{"Does not retry non-retryable errors", 5, false, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := RetryWithJitter(context.Background(), settings, func(error) bool { return tt.retryable }, func() error {
+				calls++
+				if calls <= tt.failures {
+					return failure
+				}
+				return nil
+			})
+			if calls != tt.wantCalls || (err != nil) != tt.wantErr {
+				t.Errorf("expected %d calls and err=%v, got %d calls and %v", tt.wantCalls, tt.wantErr, calls, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrNoHubAvailable = errors.New("no hub available for order")
+
+type HubRouter interface {
+	Route(ctx context.Context, order models.Order) (models.RoutingDecision, error)
+}
+
+type RealRouter struct{}
+
+func (RealRouter) Route(ctx context.Context, order models.Order) (models.RoutingDecision, error) {
+	return RouteOrder(ctx, order)
+}
+
+type RoutingRuleStore interface {
+	Save(ctx context.Context, rule models.RoutingRule) error
+	List(ctx context.Context, tenantID uuid.UUID) ([]models.RoutingRule, error)
+	Delete(ctx context.Context, tenantID, ruleID uuid.UUID) (bool, error)
+}
+
+type RealRuleStore struct{}
+
+func (RealRuleStore) Save(ctx context.Context, rule models.RoutingRule) error {
+	return SaveRoutingRule(ctx, rule)
+}
+
+func (RealRuleStore) List(ctx context.Context, tenantID uuid.UUID) ([]models.RoutingRule, error) {
+	return GetRoutingRules(ctx, tenantID)
+}
+
+func (RealRuleStore) Delete(ctx context.Context, tenantID, ruleID uuid.UUID) (bool, error) {
+	return DeleteRoutingRule(ctx, tenantID, ruleID)
+}
+
+func getRoutingRuleCollection(ctx context.Context) (*mongo.Collection, error) {
+	return getCollection(ctx, "mongo.routingRuleCollectionName")
+}
+
+func SaveRoutingRule(ctx context.Context, rule models.RoutingRule) error {
+	collection, err := getRoutingRuleCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": rule.TenantID, "rule_id": rule.RuleID}
+	update := bson.M{"$set": rule}
+	_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save routing rule:"))
+	}
+	return err
+}
+
+func GetRoutingRules(ctx context.Context, tenantID uuid.UUID) ([]models.RoutingRule, error) {
+	collection, err := getRoutingRuleCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"tenant_id": tenantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.RoutingRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func DeleteRoutingRule(ctx context.Context, tenantID, ruleID uuid.UUID) (bool, error) {
+	collection, err := getRoutingRuleCollection(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"tenant_id": tenantID, "rule_id": ruleID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// GetHubCandidates lists the tenant's hubs that IMS knows stock for, for the
+// order's SKU, with distances to the destination when it can be located.
+func GetHubCandidates(ctx context.Context, order models.Order) ([]models.HubCandidate, error) {
+	availability, err := FetchHubAvailability(ctx, order, Inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := toCandidates(availability)
+	if lat, lon, ok := ResolveCoordinates(ctx, order.ShippingAddress); ok {
+		hubs, err := GetHubLocations(ctx, order.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		candidates = AnnotateDistances(candidates, hubs, lat, lon, order.DestinationRegion)
+	}
+	return candidates, nil
+}
+
+// RouteOrder picks a hub for an order that was submitted without one.
+func RouteOrder(ctx context.Context, order models.Order) (models.RoutingDecision, error) {
+	rules, err := GetRoutingRules(ctx, order.TenantID)
+	if err != nil {
+		return models.RoutingDecision{}, err
+	}
+
+	candidates, err := GetHubCandidates(ctx, order)
+	if err != nil {
+		return models.RoutingDecision{}, err
+	}
+
+	return EvaluateRoutingRules(order, rules, candidates)
+}
+
+// RuleMatches reports whether every non-empty condition of the rule holds for the order.
+func RuleMatches(rule models.RoutingRule, order models.Order) bool {
+	c := rule.Conditions
+	if len(c.SKUIDs) > 0 && !containsUUID(c.SKUIDs, order.SKUID) {
+		return false
+	}
+	if len(c.SellerIDs) > 0 && !containsUUID(c.SellerIDs, order.SellerID) {
+		return false
+	}
+	if len(c.Regions) > 0 && !containsString(c.Regions, order.DestinationRegion) {
+		return false
+	}
+	if len(c.Tags) > 0 {
+		tagged := false
+		for _, tag := range order.Tags {
+			if containsString(c.Tags, tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+	return true
+}
+
+// EvaluateRoutingRules applies the rules in priority order. Exclude rules
+// accumulate; the first prefer, cheapest or nearest rule that can pick a hub
+// decides. Without a deciding rule the hub with the most stock wins. Hubs that
+// can ship the full quantity are always favoured over those that cannot.
+func EvaluateRoutingRules(order models.Order, rules []models.RoutingRule, candidates []models.HubCandidate) (models.RoutingDecision, error) {
+	decision := models.RoutingDecision{Candidates: candidates}
+
+	sorted := make([]models.RoutingRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority < sorted[j].Priority })
+
+	remaining := candidates
+	for _, rule := range sorted {
+		if !rule.Enabled || !RuleMatches(rule, order) {
+			continue
+		}
+
+		switch rule.Action.Type {
+		case "exclude":
+			remaining = withoutHubs(remaining, rule.Action.HubIDs)
+			decision.Explanation = append(decision.Explanation,
+				fmt.Sprintf("rule %q excluded %d hub(s)", rule.Name, len(rule.Action.HubIDs)))
+			continue
+		case "prefer":
+			for _, hubID := range rule.Action.HubIDs {
+				if hub, ok := findHub(stocked(remaining, order.Quantity), hubID); ok {
+					return decide(decision, rule, hub, fmt.Sprintf("rule %q preferred hub %s", rule.Name, hub.HubID)), nil
+				}
+			}
+		case "cheapest":
+			if hub, ok := pickHub(stocked(remaining, order.Quantity), func(a, b models.HubCandidate) bool {
+				return a.ShippingCost < b.ShippingCost
+			}); ok {
+				return decide(decision, rule, hub, fmt.Sprintf("rule %q picked cheapest hub %s", rule.Name, hub.HubID)), nil
+			}
+		case "nearest":
+			var located []models.HubCandidate
+			for _, hub := range stocked(remaining, order.Quantity) {
+				if hub.HasDistance {
+					located = append(located, hub)
+				}
+			}
+			if hub, ok := pickHub(located, func(a, b models.HubCandidate) bool {
+				return a.DistanceKm < b.DistanceKm
+			}); ok {
+				return decide(decision, rule, hub, fmt.Sprintf("rule %q picked nearest hub %s", rule.Name, hub.HubID)), nil
+			}
+		}
+		decision.Explanation = append(decision.Explanation,
+			fmt.Sprintf("rule %q matched but no eligible hub was found", rule.Name))
+	}
+
+	hub, ok := pickHub(stocked(remaining, order.Quantity), func(a, b models.HubCandidate) bool {
+		return a.AvailableQuantity > b.AvailableQuantity
+	})
+	if !ok {
+		decision.Explanation = append(decision.Explanation, "no hub left after applying rules")
+		return decision, ErrNoHubAvailable
+	}
+
+	decision.HubID = hub.HubID
+	decision.Action = "default"
+	decision.Explanation = append(decision.Explanation,
+		fmt.Sprintf("no deciding rule matched, defaulted to hub %s with the most stock", hub.HubID))
+	return decision, nil
+}
+
+func decide(decision models.RoutingDecision, rule models.RoutingRule, hub models.HubCandidate, reason string) models.RoutingDecision {
+	decision.HubID = hub.HubID
+	decision.RuleID = rule.RuleID
+	decision.RuleName = rule.Name
+	decision.Action = rule.Action.Type
+	decision.Explanation = append(decision.Explanation, reason)
+	return decision
+}
+
+// stocked narrows hubs to those that can ship the full quantity, falling back
+// to all hubs when none can.
+func stocked(hubs []models.HubCandidate, quantity int) []models.HubCandidate {
+	var result []models.HubCandidate
+	for _, hub := range hubs {
+		if hub.AvailableQuantity >= quantity {
+			result = append(result, hub)
+		}
+	}
+	if len(result) == 0 {
+		return hubs
+	}
+	return result
+}
+
+func withoutHubs(hubs []models.HubCandidate, excluded []uuid.UUID) []models.HubCandidate {
+	var result []models.HubCandidate
+	for _, hub := range hubs {
+		if !containsUUID(excluded, hub.HubID) {
+			result = append(result, hub)
+		}
+	}
+	return result
+}
+
+func findHub(hubs []models.HubCandidate, hubID uuid.UUID) (models.HubCandidate, bool) {
+	for _, hub := range hubs {
+		if hub.HubID == hubID {
+			return hub, true
+		}
+	}
+	return models.HubCandidate{}, false
+}
+
+func pickHub(hubs []models.HubCandidate, better func(a, b models.HubCandidate) bool) (models.HubCandidate, bool) {
+	if len(hubs) == 0 {
+		return models.HubCandidate{}, false
+	}
+	best := hubs[0]
+	for _, hub := range hubs[1:] {
+		if better(hub, best) {
+			best = hub
+		}
+	}
+	return best, true
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestRuleMatches(t *testing.T) {
+	sku := uuid.New()
+	seller := uuid.New()
+	order := models.Order{SKUID: sku, SellerID: seller, DestinationRegion: "north", Tags: []string{"fragile"}}
+
+	tests := []struct {
+		name       string
+		conditions models.RoutingConditions
+		expected   bool
+	}{
+		{"No conditions", models.RoutingConditions{}, true},
+		{"SKU matches", models.RoutingConditions{SKUIDs: []uuid.UUID{sku}}, true},
+		{"SKU differs", models.RoutingConditions{SKUIDs: []uuid.UUID{uuid.New()}}, false},
+		{"Seller and region match", models.RoutingConditions{SellerIDs: []uuid.UUID{seller}, Regions: []string{"north"}}, true},
+		{"Region differs", models.RoutingConditions{Regions: []string{"south"}}, false},
+		{"Tag overlaps", models.RoutingConditions{Tags: []string{"express", "fragile"}}, true},
+		{"Tag missing", models.RoutingConditions{Tags: []string{"express"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := models.RoutingRule{Conditions: tt.conditions}
+			if result := RuleMatches(rule, order); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestEvaluateRoutingRules(t *testing.T) {
+	hubA := uuid.New()
+	hubB := uuid.New()
+	hubC := uuid.New()
+	order := models.Order{SKUID: uuid.New(), Quantity: 5, DestinationRegion: "north"}
+
+	candidates := []models.HubCandidate{
+		{HubID: hubA, AvailableQuantity: 10, ShippingCost: 8},
+		{HubID: hubB, AvailableQuantity: 20, ShippingCost: 5},
+		{HubID: hubC, AvailableQuantity: 2, ShippingCost: 1},
+	}
+
+	tests := []struct {
+		name     string
+		rules    []models.RoutingRule
+		expected uuid.UUID
+		action   string
+		wantErr  error
+	}{
+		{
+			name:     "Default picks most stock",
+			expected: hubB,
+			action:   "default",
+		},
+		{
+			name: "Prefer rule wins",
+			rules: []models.RoutingRule{
+				{Name: "prefer A", Enabled: true, Action: models.RoutingAction{Type: "prefer", HubIDs: []uuid.UUID{hubA}}},
+			},
+			expected: hubA,
+			action:   "prefer",
+		},
+		{
+			name: "Cheapest skips hubs without enough stock",
+			rules: []models.RoutingRule{
+				{Name: "cheapest", Enabled: true, Action: models.RoutingAction{Type: "cheapest"}},
+			},
+			expected: hubB,
+			action:   "cheapest",
+		},
+		{
+			name: "Exclude then prefer in priority order",
+			rules: []models.RoutingRule{
+				{Name: "prefer B", Priority: 2, Enabled: true, Action: models.RoutingAction{Type: "prefer", HubIDs: []uuid.UUID{hubB, hubA}}},
+				{Name: "exclude B", Priority: 1, Enabled: true, Action: models.RoutingAction{Type: "exclude", HubIDs: []uuid.UUID{hubB}}},
+			},
+			expected: hubA,
+			action:   "prefer",
+		},
+		{
+			name: "Disabled and unmatched rules are ignored",
+			rules: []models.RoutingRule{
+				{Name: "disabled", Enabled: false, Action: models.RoutingAction{Type: "prefer", HubIDs: []uuid.UUID{hubA}}},
+				{Name: "south only", Enabled: true, Conditions: models.RoutingConditions{Regions: []string{"south"}},
+					Action: models.RoutingAction{Type: "prefer", HubIDs: []uuid.UUID{hubA}}},
+			},
+			expected: hubB,
+			action:   "default",
+		},
+		{
+			name: "Everything excluded",
+			rules: []models.RoutingRule{
+				{Name: "exclude all", Enabled: true, Action: models.RoutingAction{Type: "exclude", HubIDs: []uuid.UUID{hubA, hubB, hubC}}},
+			},
+			wantErr: ErrNoHubAvailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := EvaluateRoutingRules(order, tt.rules, candidates)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if decision.HubID != tt.expected || decision.Action != tt.action {
+				t.Errorf("expected hub %s via %s, got hub %s via %s (%v)", tt.expected, tt.action, decision.HubID, decision.Action, decision.Explanation)
+			}
+		})
+	}
+}
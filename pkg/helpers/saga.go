@@ -0,0 +1,378 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	SagaReserving = "reserving"
+	SagaReserved  = "reserved"
+	SagaConfirmed = "confirmed"
+	SagaReleasing = "releasing"
+	SagaReleased  = "released"
+)
+
+func getSagaCollection(ctx context.Context) (*mongo.Collection, error) {
+	return getCollection(ctx, "mongo.sagaCollectionName")
+}
+
+func saveSaga(ctx context.Context, saga *models.InventorySaga) error {
+	collection, err := getSagaCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	saga.UpdatedAt = time.Now()
+	filter := bson.M{"saga_id": saga.SagaID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": saga}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to persist inventory saga %s:"), saga.SagaID)
+	}
+	return err
+}
+
+// ReserveInventory reserves every line of the order in IMS, recording each
+// step, with the idempotency keys of the order's current reservation
+// attempt. When any line cannot be reserved the lines already held are
+// released and no saga is returned. On success the saga is left in the
+// reserved state and the caller must confirm or release it.
+func ReserveInventory(ctx context.Context, order models.Order, fulfilmentID uuid.UUID, inventory InventoryService) (InventoryResult, *models.InventorySaga) {
+	lines := ComponentOrders(order)
+	saga := &models.InventorySaga{
+		SagaID:       uuid.New(),
+		OrderID:      order.OrderID,
+		FulfilmentID: fulfilmentID,
+		TenantID:     order.TenantID,
+		Attempt:      order.ReservationAttempt,
+		State:        SagaReserving,
+		Lines:        make([]models.SagaLine, 0, len(lines)),
+		CreatedAt:    time.Now(),
+	}
+	for _, line := range lines {
+		saga.Lines = append(saga.Lines, models.SagaLine{SKUID: line.SKUID, HubID: line.HubID, Quantity: line.Quantity})
+	}
+
+	if err := saveSaga(ctx, saga); err != nil {
+		return InventoryResult{Status: "error"}, nil
+	}
+
+	result := InventoryResult{Status: "new_order"}
+	for i, line := range lines {
+		lineResult, err := inventory.CheckAndReserve(ctx, line, InventoryIdempotencyKey(sagaOwner(saga), saga.Attempt, i))
+		if err != nil {
+			result = InventoryResult{Status: "error"}
+			break
+		}
+		if lineResult.Status != "new_order" {
+			result = lineResult
+			break
+		}
+
+		saga.Lines[i].Reserved = true
+		if err := saveSaga(ctx, saga); err != nil {
+			result = InventoryResult{Status: "error"}
+			break
+		}
+	}
+
+	if result.Status != "new_order" {
+		if err := ReleaseSaga(ctx, saga, inventory, "reservation incomplete: "+result.Status); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Release after partial reservation failed for order %s:"), order.OrderID)
+		}
+		return result, nil
+	}
+
+	saga.State = SagaReserved
+	if err := saveSaga(ctx, saga); err != nil {
+		log.WithError(err).Warn(i18n.Translate(ctx, "Saga %s reserved but state not persisted"), saga.SagaID)
+	}
+	return result, saga
+}
+
+// ConfirmSaga marks the reservation as owned by a persisted order.
+func ConfirmSaga(ctx context.Context, saga *models.InventorySaga) error {
+	saga.State = SagaConfirmed
+	saga.LastError = ""
+	return saveSaga(ctx, saga)
+}
+
+// ReleaseSaga gives back every reserved line. Lines that fail to release stay
+// reserved and the saga is left in the releasing state for the recovery job.
+// Lines are released even when that state cannot be persisted, e.g. the kit
+// components reserved before a later component came back on hold, since
+// stock held by a stale saga is worse than a stale saga record. Each line is
+// recorded as released before the next, and its release carries an
+// idempotency key, so releasing the saga again never gives stock back twice.
+func ReleaseSaga(ctx context.Context, saga *models.InventorySaga, inventory InventoryService, reason string) error {
+	saga.State = SagaReleasing
+	saga.LastError = reason
+	saveErr := saveSaga(ctx, saga)
+
+	var failed error
+	for i, line := range saga.Lines {
+		if !line.Reserved {
+			continue
+		}
+		if err := inventory.Release(ctx, line, InventoryReleaseKey(sagaOwner(saga), saga.Attempt, i)); err != nil {
+			failed = errors.Join(failed, err)
+			continue
+		}
+		saga.Lines[i].Reserved = false
+		if err := saveSaga(ctx, saga); err != nil {
+			saveErr = errors.Join(saveErr, err)
+		}
+	}
+
+	if failed != nil {
+		saga.LastError = failed.Error()
+		_ = saveSaga(ctx, saga)
+		return errors.Join(saveErr, failed)
+	}
+
+	// IMS may have cached its answers under this attempt's keys, so the next
+	// reservation must not reuse them
+	if err := advanceReservationAttempt(ctx, saga); err != nil {
+		saga.LastError = err.Error()
+		_ = saveSaga(ctx, saga)
+		return errors.Join(saveErr, err)
+	}
+
+	saga.State = SagaReleased
+	return saveSaga(ctx, saga)
+}
+
+// releaseLostSaga releases a reservation made for an order another worker
+// moved on first. Sagas of the same attempt share their idempotency keys, so
+// IMS holds one reservation for all of them; it is left to the saga still
+// holding it instead of being released from under the winner.
+func releaseLostSaga(ctx context.Context, saga *models.InventorySaga, inventory InventoryService, reason string) error {
+	holders, err := findSagas(ctx, bson.M{
+		"order_id":      saga.OrderID,
+		"fulfilment_id": saga.FulfilmentID,
+		"attempt":       saga.Attempt,
+		"saga_id":       bson.M{"$ne": saga.SagaID},
+		"state":         bson.M{"$in": []string{SagaReserved, SagaConfirmed}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(holders) == 0 {
+		return ReleaseSaga(ctx, saga, inventory, reason)
+	}
+
+	for i := range saga.Lines {
+		saga.Lines[i].Reserved = false
+	}
+	saga.State = SagaReleased
+	saga.LastError = reason + "; reservation held by saga " + holders[0].SagaID.String()
+	return saveSaga(ctx, saga)
+}
+
+// sagaOwner is the order or fulfilment order the saga reserves for.
+func sagaOwner(saga *models.InventorySaga) uuid.UUID {
+	if saga.FulfilmentID != uuid.Nil {
+		return saga.FulfilmentID
+	}
+	return saga.OrderID
+}
+
+// advanceReservationAttempt moves the order past the saga's attempt. $max
+// keeps it from going back when sagas of older attempts are released late.
+func advanceReservationAttempt(ctx context.Context, saga *models.InventorySaga) error {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"order_id": saga.OrderID},
+		bson.M{"$max": bson.M{"reservation_attempt": saga.Attempt + 1}},
+	)
+	return err
+}
+
+func findSagas(ctx context.Context, filter bson.M) ([]models.InventorySaga, error) {
+	collection, err := getSagaCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sagas []models.InventorySaga
+	if err := cursor.All(ctx, &sagas); err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+// ReleaseOrderInventory releases every reservation held for the order, e.g. on cancellation.
+func ReleaseOrderInventory(ctx context.Context, orderID uuid.UUID, reason string) error {
+	sagas, err := findSagas(ctx, bson.M{
+		"order_id": orderID,
+		"state":    bson.M{"$in": []string{SagaReserving, SagaReserved, SagaConfirmed, SagaReleasing}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var failed error
+	for i := range sagas {
+		if err := ReleaseSaga(ctx, &sagas[i], Inventory, reason); err != nil {
+			failed = errors.Join(failed, err)
+		}
+	}
+	return failed
+}
+
+// RecoverSagas resumes sagas left half-finished, e.g. by a restart. A
+// reservation is confirmed when its order (or fulfilment order) reached
+// new_order and released otherwise. Recovery stops as soon as CheckFence
+// reports a newer leader.
+func RecoverSagas(ctx context.Context, staleAfter time.Duration) (int, error) {
+	sagas, err := findSagas(ctx, bson.M{
+		"state":      bson.M{"$in": []string{SagaReserving, SagaReserved, SagaReleasing}},
+		"updated_at": bson.M{"$lt": time.Now().Add(-staleAfter)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for i := range sagas {
+		// A former leader stops before it releases sagas its successor is recovering
+		if err := CheckFence(ctx); err != nil {
+			return recovered, err
+		}
+		saga := &sagas[i]
+
+		if saga.State == SagaReserved {
+			allocated, err := reservationOwnerAllocated(ctx, *saga)
+			if err != nil {
+				log.WithError(err).Warn(i18n.Translate(ctx, "Could not inspect owner of saga %s:"), saga.SagaID)
+				continue
+			}
+			if allocated {
+				if err := ConfirmSaga(ctx, saga); err == nil {
+					recovered++
+				}
+				continue
+			}
+		}
+
+		if err := ReleaseSaga(ctx, saga, Inventory, "recovered after restart"); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Failed to release saga %s:"), saga.SagaID)
+			continue
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+func reservationOwnerAllocated(ctx context.Context, saga models.InventorySaga) (bool, error) {
+	key, filter := "mongo.collectionName", bson.M{"order_id": saga.OrderID}
+	if saga.FulfilmentID != uuid.Nil {
+		key, filter = "mongo.fulfilmentCollectionName", bson.M{"fulfilment_id": saga.FulfilmentID}
+	}
+
+	collection, err := getCollection(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	var owner struct {
+		Status string `bson:"status"`
+	}
+	err = collection.FindOne(ctx, filter).Decode(&owner)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner.Status == "new_order", nil
+}
+
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderNotCancellable = errors.New("order cannot be cancelled in its current status")
+)
+
+type OrderCanceller interface {
+	Cancel(ctx context.Context, tenantID, orderID uuid.UUID) (models.Order, error)
+}
+
+type RealCanceller struct{}
+
+func (RealCanceller) Cancel(ctx context.Context, tenantID, orderID uuid.UUID) (models.Order, error) {
+	return CancelOrder(ctx, tenantID, orderID)
+}
+
+var cancellableStatuses = []string{"pre_order", "on_hold", "backordered", "partially_allocated", "new_order"}
+
+// CancelOrder cancels the order and its fulfilment orders and releases any
+// stock reserved for them.
+func CancelOrder(ctx context.Context, tenantID, orderID uuid.UUID) (models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	var order models.Order
+	err = collection.FindOne(ctx, bson.M{"order_id": orderID, "tenant_id": tenantID}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Order{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if !containsString(cancellableStatuses, order.Status) {
+		return order, ErrOrderNotCancellable
+	}
+
+	if err := ReleaseOrderInventory(ctx, orderID, "order cancelled"); err != nil {
+		// The recovery job retries sagas left in the releasing state
+		log.WithError(err).Warn(i18n.Translate(ctx, "Inventory release incomplete for cancelled order %s:"), orderID)
+	}
+
+	if err := UpdateOrderStatus(ctx, orderID, "cancelled"); err != nil {
+		return order, err
+	}
+
+	if order.Status == "pre_order" {
+		if err := ReleasePreorder(ctx, order); err != nil {
+			log.WithError(err).Warn(i18n.Translate(ctx, "Pre-order count not released for cancelled order %s:"), orderID)
+		}
+	}
+
+	if order.IsSplit {
+		fulfilments, err := getFulfilmentCollection(ctx)
+		if err != nil {
+			return order, err
+		}
+		update := bson.M{"$set": bson.M{"status": "cancelled", "updated_at": time.Now()}}
+		if _, err := fulfilments.UpdateMany(ctx, bson.M{"parent_order_id": orderID}, update); err != nil {
+			return order, err
+		}
+	}
+
+	previous := order.Status
+	order.Status = "cancelled"
+	EmitOrderEvent(ctx, models.EventOrderCancelled, order, previous)
+	return order, nil
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrOrderNotShippable = errors.New("order cannot be shipped in its current status")
+
+type OrderShipper interface {
+	Ship(ctx context.Context, tenantID, orderID uuid.UUID, shipment models.Shipment) (models.Order, error)
+}
+
+type RealShipper struct{}
+
+func (RealShipper) Ship(ctx context.Context, tenantID, orderID uuid.UUID, shipment models.Shipment) (models.Order, error) {
+	return ShipOrder(ctx, tenantID, orderID, shipment)
+}
+
+// ShipOrder marks a fully allocated order and its fulfilment orders as
+// shipped and publishes order.shipped.
+func ShipOrder(ctx context.Context, tenantID, orderID uuid.UUID, shipment models.Shipment) (models.Order, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	var order models.Order
+	err = collection.FindOne(ctx, bson.M{"order_id": orderID, "tenant_id": tenantID}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Order{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if shipment.ShippedAt.IsZero() {
+		shipment.ShippedAt = time.Now()
+	}
+
+	// Matching on the status keeps a concurrent cancel from being overwritten
+	update := bson.M{"$set": bson.M{"status": "shipped", "shipment": shipment, "updated_at": time.Now()}}
+	result, err := collection.UpdateOne(ctx, bson.M{"order_id": orderID, "tenant_id": tenantID, "status": "new_order"}, update)
+	if err != nil {
+		return order, err
+	}
+	if result.MatchedCount == 0 {
+		return order, ErrOrderNotShippable
+	}
+
+	if order.IsSplit {
+		fulfilments, err := getFulfilmentCollection(ctx)
+		if err != nil {
+			return order, err
+		}
+		update := bson.M{"$set": bson.M{"status": "shipped", "updated_at": time.Now()}}
+		if _, err := fulfilments.UpdateMany(ctx, bson.M{"parent_order_id": orderID, "tenant_id": tenantID}, update); err != nil {
+			return order, err
+		}
+	}
+
+	previous := order.Status
+	order.Status = "shipped"
+	order.Shipment = &shipment
+	EmitOrderEvent(ctx, models.EventOrderShipped, order, previous)
+	return order, nil
+}
@@ -0,0 +1,237 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Hubs without a cut-off time accept orders for the day until midnight.
+const defaultCutoffTime = "23:59"
+
+type SLAPolicyStore interface {
+	Save(ctx context.Context, policy models.SLAPolicy) error
+	Get(ctx context.Context, tenantID uuid.UUID) (models.SLAPolicy, bool, error)
+}
+
+type RealSLAPolicyStore struct{}
+
+func (RealSLAPolicyStore) Save(ctx context.Context, policy models.SLAPolicy) error {
+	return SaveSLAPolicy(ctx, policy)
+}
+
+func (RealSLAPolicyStore) Get(ctx context.Context, tenantID uuid.UUID) (models.SLAPolicy, bool, error) {
+	return GetSLAPolicy(ctx, tenantID)
+}
+
+// SLAPlanner sets the ship-by deadline of a new order.
+type SLAPlanner interface {
+	Plan(ctx context.Context, order models.Order) (models.Order, error)
+}
+
+type RealSLAPlanner struct{}
+
+func (RealSLAPlanner) Plan(ctx context.Context, order models.Order) (models.Order, error) {
+	return PlanShipBy(ctx, order)
+}
+
+func SaveSLAPolicy(ctx context.Context, policy models.SLAPolicy) error {
+	collection, err := getCollection(ctx, "mongo.slaPolicyCollectionName")
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"tenant_id": policy.TenantID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{"$set": policy}, options.Update().SetUpsert(true))
+	if err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to save SLA policy:"))
+	}
+	return err
+}
+
+// GetSLAPolicy returns the tenant's SLA policy and whether it has one.
+func GetSLAPolicy(ctx context.Context, tenantID uuid.UUID) (models.SLAPolicy, bool, error) {
+	var policy models.SLAPolicy
+
+	collection, err := getCollection(ctx, "mongo.slaPolicyCollectionName")
+	if err != nil {
+		return policy, false, err
+	}
+
+	err = collection.FindOne(ctx, bson.M{"tenant_id": tenantID}).Decode(&policy)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return policy, false, nil
+	}
+	if err != nil {
+		return policy, false, err
+	}
+	return policy, true, nil
+}
+
+// GetHub returns the tenant's hub and whether it is known to OMS.
+func GetHub(ctx context.Context, tenantID, hubID uuid.UUID) (models.Hub, bool, error) {
+	var hub models.Hub
+
+	collection, err := getCollection(ctx, "mongo.hubCollectionName")
+	if err != nil {
+		return hub, false, err
+	}
+
+	err = collection.FindOne(ctx, bson.M{"tenant_id": tenantID, "hub_id": hubID}).Decode(&hub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return hub, false, nil
+	}
+	if err != nil {
+		return hub, false, err
+	}
+	return hub, true, nil
+}
+
+// SLALevelFor picks the level with the highest priority not above the
+// order's priority.
+func SLALevelFor(policy models.SLAPolicy, priority int) (models.SLALevel, bool) {
+	var level models.SLALevel
+	found := false
+	for _, l := range policy.Levels {
+		if l.Priority > priority {
+			continue
+		}
+		if !found || l.Priority > level.Priority {
+			level, found = l, true
+		}
+	}
+	return level, found
+}
+
+// ComputeShipBy returns the hub cut-off handlingDays after the order's
+// dispatch day. Orders placed after the day's cut-off count from the next day.
+func ComputeShipBy(createdAt time.Time, handlingDays int, hub models.Hub) time.Time {
+	loc := time.UTC
+	if hub.Timezone != "" {
+		if l, err := time.LoadLocation(hub.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	cutoffTime := hub.CutoffTime
+	if cutoffTime == "" {
+		cutoffTime = defaultCutoffTime
+	}
+	cutoff, err := time.Parse("15:04", cutoffTime)
+	if err != nil {
+		cutoff, _ = time.Parse("15:04", defaultCutoffTime)
+	}
+
+	local := createdAt.In(loc)
+	dispatch := time.Date(local.Year(), local.Month(), local.Day(), cutoff.Hour(), cutoff.Minute(), 0, 0, loc)
+	if local.After(dispatch) {
+		dispatch = dispatch.AddDate(0, 0, 1)
+	}
+	return dispatch.AddDate(0, 0, handlingDays).UTC()
+}
+
+// PlanShipBy sets the order's ship-by deadline from the tenant's SLA policy
+// and the hub's cut-off. Orders of tenants without a policy get no deadline.
+func PlanShipBy(ctx context.Context, order models.Order) (models.Order, error) {
+	policy, found, err := GetSLAPolicy(ctx, order.TenantID)
+	if err != nil || !found {
+		return order, err
+	}
+
+	level, ok := SLALevelFor(policy, order.Priority)
+	if !ok {
+		return order, nil
+	}
+
+	hub, _, err := GetHub(ctx, order.TenantID, order.HubID)
+	if err != nil {
+		return order, err
+	}
+
+	shipBy := ComputeShipBy(order.CreatedAt, level.HandlingDays, hub)
+	order.ShipBy = &shipBy
+	return order, nil
+}
+
+// ClassifySLA returns the SLA state of an order due at shipBy: breached once
+// the deadline has passed, at risk within window of it, otherwise none.
+func ClassifySLA(shipBy, now time.Time, window time.Duration) string {
+	switch {
+	case !now.Before(shipBy):
+		return models.SLABreached
+	case shipBy.Sub(now) <= window:
+		return models.SLAAtRisk
+	default:
+		return ""
+	}
+}
+
+// CheckSLAs flags open orders due within window of now and calls notify
+// once per order each time its SLA state gets worse. It returns how many
+// orders were flagged.
+func CheckSLAs(ctx context.Context, now time.Time, window time.Duration, notify func(context.Context, models.SLAAlert)) (int, error) {
+	collection, err := getCollection(ctx, "mongo.collectionName")
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{
+		"ship_by":    bson.M{"$lte": now.Add(window)},
+		"status":     bson.M{"$nin": slaClosedStatuses},
+		"sla_status": bson.M{"$ne": models.SLABreached},
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "ship_by", Value: 1}}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return 0, err
+	}
+
+	flagged := 0
+	for _, order := range orders {
+		state := ClassifySLA(*order.ShipBy, now, window)
+		if state == "" || state == order.SLAStatus {
+			continue
+		}
+
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"order_id": order.OrderID, "tenant_id": order.TenantID},
+			bson.M{"$set": bson.M{"sla_status": state}},
+		)
+		if err != nil {
+			log.Errorf(i18n.Translate(ctx, "Failed to flag SLA for order %s: %v"), order.OrderID, err)
+			continue
+		}
+		flagged++
+
+		order.SLAStatus = state
+		EmitOrderEvent(ctx, models.EventOrderUpdated, order, "", "sla_status")
+
+		notify(ctx, models.SLAAlert{
+			Event:     "order.sla_" + state,
+			OrderID:   order.OrderID,
+			TenantID:  order.TenantID,
+			Status:    order.Status,
+			Priority:  order.Priority,
+			ShipBy:    *order.ShipBy,
+			SLAStatus: state,
+		})
+	}
+	return flagged, nil
+}
+
+// Orders in these states have left OMS's hands and no longer count against
+// their SLA.
+var slaClosedStatuses = []string{"cancelled", "failed", "shipped", "delivered"}
@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+)
+
+func TestComputeShipBy(t *testing.T) {
+	dubai, err := time.LoadLocation("Asia/Dubai")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+
+	tests := []struct {
+		name         string
+		createdAt    time.Time
+		handlingDays int
+		hub          models.Hub
+		want         time.Time
+	}{
+		{
+			name:         "Before Cut-off Ships From Today",
+			createdAt:    time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC),
+			handlingDays: 1,
+			hub:          models.Hub{CutoffTime: "14:00"},
+			want:         time.Date(2025, 7, 2, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "After Cut-off Ships From Tomorrow",
+			createdAt:    time.Date(2025, 7, 1, 15, 0, 0, 0, time.UTC),
+			handlingDays: 1,
+			hub:          models.Hub{CutoffTime: "14:00"},
+			want:         time.Date(2025, 7, 3, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "Same Day",
+			createdAt:    time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC),
+			handlingDays: 0,
+			hub:          models.Hub{CutoffTime: "14:00"},
+			want:         time.Date(2025, 7, 1, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "No Cut-off Uses End Of Day",
+			createdAt:    time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC),
+			handlingDays: 2,
+			hub:          models.Hub{},
+			want:         time.Date(2025, 7, 3, 23, 59, 0, 0, time.UTC),
+		},
+		{
+			name:         "Cut-off In Hub Timezone",
+			createdAt:    time.Date(2025, 7, 1, 11, 0, 0, 0, time.UTC), // 15:00 in Dubai
+			handlingDays: 1,
+			hub:          models.Hub{CutoffTime: "14:00", Timezone: "Asia/Dubai"},
+			want:         time.Date(2025, 7, 3, 14, 0, 0, 0, dubai).UTC(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeShipBy(tt.createdAt, tt.handlingDays, tt.hub)
+			if !got.Equal(tt.want) {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSLALevelFor(t *testing.T) {
+	policy := models.SLAPolicy{Levels: []models.SLALevel{
+		{Name: "express", Priority: 5, HandlingDays: 0},
+		{Name: "standard", Priority: 0, HandlingDays: 3},
+		{Name: "priority", Priority: 2, HandlingDays: 1},
+	}}
+
+	tests := []struct {
+		priority int
+		want     string
+	}{
+		{0, "standard"},
+		{1, "standard"},
+		{2, "priority"},
+		{4, "priority"},
+		{9, "express"},
+	}
+
+	for _, tt := range tests {
+		level, ok := SLALevelFor(policy, tt.priority)
+		if !ok || level.Name != tt.want {
+			t.Errorf("priority %d: expected %s, got %s (found=%v)", tt.priority, tt.want, level.Name, ok)
+		}
+	}
+
+	if _, ok := SLALevelFor(models.SLAPolicy{Levels: []models.SLALevel{{Priority: 3}}}, 1); ok {
+		t.Error("expected no level below the lowest configured priority")
+	}
+}
+
+func TestClassifySLA(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		shipBy time.Time
+		want   string
+	}{
+		{"On Track", now.Add(3 * time.Hour), ""},
+		{"At Risk", now.Add(time.Hour), models.SLAAtRisk},
+		{"Due Now", now, models.SLABreached},
+		{"Breached", now.Add(-time.Minute), models.SLABreached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifySLA(tt.shipBy, now, 2*time.Hour); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
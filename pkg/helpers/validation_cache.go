@@ -0,0 +1,179 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// ValidationStore is the subset of the Redis client used to cache IMS
+// validation results.
+type ValidationStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) (int64, error)
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// ValidationCacheStats are the hit/miss counts since the process started.
+type ValidationCacheStats struct {
+	Enabled bool    `json:"enabled"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Errors  int64   `json:"errors"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// ValidationInvalidation names the cached results to drop for a tenant.
+type ValidationInvalidation struct {
+	TenantID uuid.UUID `json:"tenant_id" binding:"required"`
+	SKUID    uuid.UUID `json:"sku_id,omitempty"`
+	HubID    uuid.UUID `json:"hub_id,omitempty"`
+}
+
+var (
+	validationStore       ValidationStore
+	validationPositiveTTL = 10 * time.Minute
+	validationNegativeTTL = time.Minute
+
+	validationHits   atomic.Int64
+	validationMisses atomic.Int64
+	validationErrors atomic.Int64
+)
+
+// InitValidationCache enables caching of IMS validation results in store.
+func InitValidationCache(ctx context.Context, store ValidationStore) {
+	if !config.GetBool(ctx, "validation_cache.enabled") {
+		log.Infof(i18n.Translate(ctx, "Validation cache disabled"))
+		return
+	}
+	if ttl := config.GetDuration(ctx, "validation_cache.positive_ttl"); ttl > 0 {
+		validationPositiveTTL = ttl
+	}
+	if ttl := config.GetDuration(ctx, "validation_cache.negative_ttl"); ttl > 0 {
+		validationNegativeTTL = ttl
+	}
+	validationStore = store
+}
+
+func GetValidationCacheStats() ValidationCacheStats {
+	stats := ValidationCacheStats{
+		Enabled: validationStore != nil,
+		Hits:    validationHits.Load(),
+		Misses:  validationMisses.Load(),
+		Errors:  validationErrors.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// Keys carry a per-tenant generation so a whole tenant can be invalidated
+// by bumping one counter instead of scanning for keys.
+func validationGenerationKey(tenantID uuid.UUID) string {
+	return fmt.Sprintf("ims:validation:%s:generation", tenantID)
+}
+
+func validationKey(ctx context.Context, tenantID uuid.UUID, kind string, ids ...uuid.UUID) string {
+	generation, err := validationStore.Get(ctx, validationGenerationKey(tenantID))
+	if err != nil || generation == "" {
+		// A missing counter reads as generation 0
+		generation = "0"
+	}
+
+	key := fmt.Sprintf("ims:validation:%s:%s:%s", tenantID, generation, kind)
+	for _, id := range ids {
+		key += ":" + id.String()
+	}
+	return key
+}
+
+// LookupValidation returns the cached result for the tenant's SKU, hub or
+// SKU/hub pair (kind "sku", "hub" or "pair") and whether there was one.
+func LookupValidation(ctx context.Context, tenantID uuid.UUID, kind string, ids ...uuid.UUID) (valid, found bool) {
+	if validationStore == nil {
+		return false, false
+	}
+
+	cached, err := validationStore.Get(ctx, validationKey(ctx, tenantID, kind, ids...))
+	if err == nil && cached != "" {
+		validationHits.Add(1)
+		return cached == "1", true
+	}
+	validationMisses.Add(1)
+	return false, false
+}
+
+// StoreValidation caches a definite IMS answer, valid ones for the positive
+// TTL and invalid ones for the shorter negative TTL.
+func StoreValidation(ctx context.Context, tenantID uuid.UUID, kind string, valid bool, ids ...uuid.UUID) {
+	if validationStore == nil {
+		return
+	}
+
+	key := validationKey(ctx, tenantID, kind, ids...)
+	value, ttl := "0", validationNegativeTTL
+	if valid {
+		value, ttl = "1", validationPositiveTTL
+	}
+	if _, err := validationStore.Set(ctx, key, value, ttl); err != nil {
+		validationErrors.Add(1)
+		log.Warnf(i18n.Translate(ctx, "Failed to cache validation result %s: %v"), key, err)
+	}
+}
+
+// CachedValidation returns the cached result, calling validate on a miss.
+// Errors from validate are never cached.
+func CachedValidation(ctx context.Context, tenantID uuid.UUID, kind string, validate func() (bool, error), ids ...uuid.UUID) (bool, error) {
+	if valid, found := LookupValidation(ctx, tenantID, kind, ids...); found {
+		return valid, nil
+	}
+
+	valid, err := validate()
+	if err != nil {
+		return false, err
+	}
+	StoreValidation(ctx, tenantID, kind, valid, ids...)
+	return valid, nil
+}
+
+// InvalidateValidation drops cached results after a catalog change in IMS.
+// For a SKU and hub together the three affected entries are deleted. Pair
+// entries cannot be found from one side only, so any broader invalidation
+// bumps the tenant generation instead.
+func InvalidateValidation(ctx context.Context, inv ValidationInvalidation) error {
+	if validationStore == nil {
+		return nil
+	}
+
+	if inv.SKUID == uuid.Nil || inv.HubID == uuid.Nil {
+		generation, err := validationStore.Incr(ctx, validationGenerationKey(inv.TenantID))
+		if err != nil {
+			return err
+		}
+		log.Infof(i18n.Translate(ctx, "Validation cache for tenant %s moved to generation %d"), inv.TenantID, generation)
+		return nil
+	}
+
+	var keys []string
+	for _, k := range []struct {
+		kind string
+		ids  []uuid.UUID
+	}{
+		{"sku", []uuid.UUID{inv.SKUID}},
+		{"hub", []uuid.UUID{inv.HubID}},
+		{"pair", []uuid.UUID{inv.HubID, inv.SKUID}},
+	} {
+		keys = append(keys, validationKey(ctx, inv.TenantID, k.kind, k.ids...))
+	}
+
+	_, err := validationStore.Del(ctx, keys...)
+	return err
+}
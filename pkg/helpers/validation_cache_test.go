@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryValidationStore struct {
+	values map[string]string
+}
+
+func (m *memoryValidationStore) Get(ctx context.Context, key string) (string, error) {
+	value, ok := m.values[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return value, nil
+}
+
+func (m *memoryValidationStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	m.values[key] = value.(string)
+	return true, nil
+}
+
+func (m *memoryValidationStore) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if _, ok := m.values[key]; ok {
+		return false, nil
+	}
+	m.values[key] = value.(string)
+	return true, nil
+}
+
+func (m *memoryValidationStore) Del(ctx context.Context, keys ...string) (int64, error) {
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := m.values[key]; ok {
+			delete(m.values, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryValidationStore) Incr(ctx context.Context, key string) (int64, error) {
+	n, _ := strconv.ParseInt(m.values[key], 10, 64)
+	n++
+	m.values[key] = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func TestCachedValidation(t *testing.T) {
+	ctx := context.Background()
+	validationStore = &memoryValidationStore{values: map[string]string{}}
+	defer func() { validationStore = nil }()
+
+	tenant, sku, hub := uuid.New(), uuid.New(), uuid.New()
+	calls := 0
+	validate := func(valid bool, err error) func() (bool, error) {
+		return func() (bool, error) {
+			calls++
+			return valid, err
+		}
+	}
+
+	// Errors are not cached
+	if _, err := CachedValidation(ctx, tenant, "sku", validate(false, errors.New("timeout")), sku); err == nil {
+		t.Fatal("expected error to be returned")
+	}
+	if valid, _ := CachedValidation(ctx, tenant, "sku", validate(true, nil), sku); !valid || calls != 2 {
+		t.Fatalf("expected fresh IMS call after error, got valid=%v calls=%d", valid, calls)
+	}
+
+	// Positive and negative results are served from the cache
+	if valid, _ := CachedValidation(ctx, tenant, "sku", validate(false, nil), sku); !valid || calls != 2 {
+		t.Fatalf("expected cached valid result, got valid=%v calls=%d", valid, calls)
+	}
+	CachedValidation(ctx, tenant, "hub", validate(false, nil), hub)
+	if valid, _ := CachedValidation(ctx, tenant, "hub", validate(true, nil), hub); valid || calls != 3 {
+		t.Fatalf("expected cached invalid result, got valid=%v calls=%d", valid, calls)
+	}
+
+	// Other tenants do not share entries
+	if CachedValidation(ctx, uuid.New(), "sku", validate(true, nil), sku); calls != 4 {
+		t.Fatalf("expected miss for another tenant, got calls=%d", calls)
+	}
+
+	// Invalidating the tenant drops every entry
+	if err := InvalidateValidation(ctx, ValidationInvalidation{TenantID: tenant}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if CachedValidation(ctx, tenant, "sku", validate(true, nil), sku); calls != 5 {
+		t.Fatalf("expected miss after invalidation, got calls=%d", calls)
+	}
+
+	// Invalidating a SKU and hub drops their entries only
+	other := uuid.New()
+	CachedValidation(ctx, tenant, "sku", validate(true, nil), other)
+	if err := InvalidateValidation(ctx, ValidationInvalidation{TenantID: tenant, SKUID: sku, HubID: hub}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if CachedValidation(ctx, tenant, "sku", validate(true, nil), sku); calls != 7 {
+		t.Fatalf("expected miss for invalidated SKU, got calls=%d", calls)
+	}
+	if CachedValidation(ctx, tenant, "sku", validate(true, nil), other); calls != 7 {
+		t.Fatalf("expected hit for untouched SKU, got calls=%d", calls)
+	}
+}
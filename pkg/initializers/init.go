@@ -2,40 +2,153 @@ package initializers
 
 import (
 	"context"
-	"time"
+	"errors"
+	"net"
+	nethttp "net/http"
 
 	localConfig "github.com/aditya-goyal-omniful/oms/pkg/configs"
 	"github.com/aditya-goyal-omniful/oms/pkg/controllers"
 	"github.com/aditya-goyal-omniful/oms/pkg/database"
 	"github.com/aditya-goyal-omniful/oms/pkg/entities"
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
 	"github.com/aditya-goyal-omniful/oms/pkg/services"
-	"github.com/aditya-goyal-omniful/oms/pkg/utils"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
 )
 
-func InitServices(ctx context.Context) {
-	utils.InitHTTPClient(ctx)
+// InitServices lists OMS's components in dependency order: clients first,
+// then the consumers and workers using them. Nothing that produces orders
+// starts before the Kafka consumer is subscribed. Nothing runs until the
+// returned lifecycle is started.
+func InitServices(ctx context.Context) *Lifecycle {
+	app := NewLifecycle()
 
-	database.ConnectDB(ctx) 						// Initialize Mongo Client
+	app.Add(Component{
+		Name: "inventory",
+		Start: func(ctx context.Context) error {
+			helpers.InitIMSResilience(ctx)    // Circuit breaker and bulkhead around IMS calls
+			helpers.InitInventoryService(ctx) // IMS client, or the in-memory fake
+			return nil
+		},
+	})
 
-	services.InitRedis(ctx)							// Initialize Redis
+	app.Add(Component{
+		Name: "mongo",
+		Start: func(ctx context.Context) error {
+			database.ConnectDB(ctx) // Initialize Mongo Client
+			return nil
+		},
+		Stop: database.DisconnectDB,
+	})
 
-	localConfig.ConnectS3(ctx) 						// Initialize S3 client
+	app.Add(Component{
+		Name: "redis",
+		Start: func(ctx context.Context) error {
+			services.InitRedis(ctx)                                // Initialize Redis
+			helpers.InitValidationCache(ctx, services.RedisClient) // Cache IMS validation results in Redis
+			return nil
+		},
+		Stop: services.CloseRedis,
+	})
 
-	localConfig.SQSInit(ctx)   						// Initialize SQS client
-	newQueue := localConfig.GetSqs() 
+	app.Add(Component{
+		Name: "s3",
+		Start: func(ctx context.Context) error {
+			localConfig.ConnectS3(ctx) // Initialize S3 client
+			return nil
+		},
+	})
 
-	localConfig.PublisherInit(ctx, newQueue)    	// Initialize SQS Publisher
-	localConfig.ConsumerInit(ctx)     		   		// Initialize SQS Consumer
-	localConfig.StartConsumer(ctx) 					// Start the SQS consumer for processing CSV files
+	app.Add(Component{
+		Name:  "kafka-producer",
+		Start: services.InitKafkaProducer,
+		Stop:  services.CloseKafkaProducer,
+	})
 
-	entities.InitCSV(ctx)							// Initialize Order Mongo Collection
+	app.Add(Component{
+		Name:  "kafka-consumer",
+		Start: services.StartKafkaConsumer, // Consume order.created, validation and inventory events
+		Stop:  services.StopKafkaConsumer,  // Drain messages being handled
+	})
 
-	go services.InitKafkaConsumer(ctx) 				// Initialize Kafka Producer
+	app.Add(Component{
+		Name: "sqs",
+		Start: func(ctx context.Context) error {
+			localConfig.SQSInit(ctx) // Initialize SQS client
+			newQueue := localConfig.GetSqs()
 
-	time.Sleep(3 * time.Second)						// Sleep to allow consumer to initialize
+			localConfig.PublisherInit(ctx, newQueue) // Initialize SQS Publisher
+			localConfig.ConsumerInit(ctx)            // Initialize SQS Consumer
 
-	services.InitKafkaProducer(ctx)					// Then produce messages
-	services.StartOrderRetryWorker()
+			entities.InitCSV(ctx)          // Initialize Order Mongo Collection
+			localConfig.StartConsumer(ctx) // Start the SQS consumer for processing CSV files
+			return nil
+		},
+		Stop: localConfig.StopConsumer, // Drain CSV batches being processed
+	})
 
-	controllers.InitWebhook(ctx)					// Initialize Webhook Mongo Collection
-}
\ No newline at end of file
+	// Workers stop scheduling runs when their context is cancelled and are then
+	// given until the shutdown deadline to finish the run in progress
+	workersCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	app.Add(Component{
+		Name: "workers",
+		Start: func(ctx context.Context) error {
+			services.StartOrderRetryWorker(workersCtx)
+			services.StartPreorderReleaseWorker(workersCtx) // Release pre-orders once their SKU launches
+			services.StartSagaRecoveryWorker(workersCtx)    // Resume half-finished inventory reservations
+			services.StartSLAMonitor(workersCtx)            // Flag orders close to or past their ship-by
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			stopWorkers()
+			return services.StopWorkers(ctx)
+		},
+	})
+
+	app.Add(Component{
+		Name: "webhooks",
+		Start: func(ctx context.Context) error {
+			controllers.InitWebhook(ctx) // Initialize Webhook Mongo Collection
+			return nil
+		},
+	})
+
+	return app
+}
+
+// HTTPServer serves handler on `server.port`. It is ready once the port is
+// bound; stopping it refuses new connections and waits for the requests in
+// flight.
+func HTTPServer(handler nethttp.Handler) Component {
+	var server *nethttp.Server
+
+	return Component{
+		Name: "http",
+		Start: func(ctx context.Context) error {
+			server = &nethttp.Server{
+				Addr:         config.GetString(ctx, "server.port"),
+				Handler:      handler,
+				ReadTimeout:  config.GetDuration(ctx, "server.read_timeout"),
+				WriteTimeout: config.GetDuration(ctx, "server.write_timeout"),
+				IdleTimeout:  config.GetDuration(ctx, "server.idle_timeout"),
+			}
+
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+
+			log.Infof(i18n.Translate(ctx, "Serving %s on %s"), config.GetString(ctx, "server.name"), server.Addr)
+			go func() {
+				if err := server.Serve(listener); err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+					log.Errorf(i18n.Translate(ctx, "HTTP server stopped: %v"), err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package initializers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// Component is a part of OMS that is started and stopped with it. Start
+// returns once the component is ready for the ones started after it. Either
+// func may be nil.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Lifecycle starts components in the order they were added and stops them in
+// reverse, so every component stops before the ones it depends on.
+type Lifecycle struct {
+	components []Component
+	started    []Component
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+func (l *Lifecycle) Add(components ...Component) {
+	l.components = append(l.components, components...)
+}
+
+// Start starts the components in order. If one fails, the ones already
+// started are stopped again and its error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for _, component := range l.components {
+		log.Infof(i18n.Translate(ctx, "Starting %s"), component.Name)
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", component.Name, err)
+				return errors.Join(err, l.Stop(ctx))
+			}
+		}
+		l.started = append(l.started, component)
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Every component gets
+// stopped even after an earlier one failed or ctx expired, so that clients
+// are still closed.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(l.started) - 1; i >= 0; i-- {
+		component := l.started[i]
+		if component.Stop == nil {
+			continue
+		}
+		log.Infof(i18n.Translate(ctx, "Stopping %s"), component.Name)
+		if err := component.Stop(ctx); err != nil {
+			log.Warnf(i18n.Translate(ctx, "Failed to stop %s cleanly: %v"), component.Name, err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", component.Name, err))
+		}
+	}
+	l.started = nil
+	return errors.Join(errs...)
+}
+
+// Run starts the components and blocks until SIGINT or SIGTERM, then stops
+// them within `lifecycle.shutdown_timeout`.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+
+	signalCtx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	<-signalCtx.Done()
+
+	timeout := config.GetDuration(ctx, "lifecycle.shutdown_timeout")
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	log.Infof(i18n.Translate(ctx, "Shutting down, waiting up to %s for work in progress"), timeout)
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	return l.Stop(stopCtx)
+}
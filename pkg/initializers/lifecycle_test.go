@@ -0,0 +1,75 @@
+package initializers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	tests := []struct {
+		name       string
+		failStart  string
+		failStop   string
+		wantEvents []string
+		wantErr    bool
+	}{
+		{
+			name:       "Starts In Order And Stops In Reverse",
+			wantEvents: []string{"start mongo", "start kafka", "start http", "stop http", "stop kafka", "stop mongo"},
+		},
+		{
+			name:       "Failed Start Stops Started Components",
+			failStart:  "kafka",
+			wantEvents: []string{"start mongo", "start kafka", "stop mongo"},
+			wantErr:    true,
+		},
+		{
+			name:       "Failed Stop Still Stops The Rest",
+			failStop:   "kafka",
+			wantEvents: []string{"start mongo", "start kafka", "start http", "stop http", "stop kafka", "stop mongo"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []string
+			component := func(name string) Component {
+				return Component{
+					Name: name,
+					Start: func(ctx context.Context) error {
+						events = append(events, "start "+name)
+						if name == tc.failStart {
+							return errors.New("unavailable")
+						}
+						return nil
+					},
+					Stop: func(ctx context.Context) error {
+						events = append(events, "stop "+name)
+						if name == tc.failStop {
+							return errors.New("timed out")
+						}
+						return nil
+					},
+				}
+			}
+
+			app := NewLifecycle()
+			app.Add(component("mongo"), component("kafka"), component("http"))
+
+			err := app.Start(context.Background())
+			if err == nil {
+				err = app.Stop(context.Background())
+			}
+
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(events, tc.wantEvents) {
+				t.Errorf("expected events %v, got %v", tc.wantEvents, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Allocation policies decide which held order gets scarce stock first.
+const (
+	AllocationFIFO          = "fifo"           // oldest order first
+	AllocationPriorityFirst = "priority_first" // highest priority first, then earliest ship-by, then oldest
+	AllocationSmallestFirst = "smallest_first" // smallest quantity first, then oldest
+)
+
+// AllocationPolicy is the tenant's choice of allocation policy.
+type AllocationPolicy struct {
+	TenantID  uuid.UUID `json:"tenant_id" bson:"tenant_id"`
+	Policy    string    `json:"policy" bson:"policy" binding:"required,oneof=fifo priority_first smallest_first"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
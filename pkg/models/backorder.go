@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BackorderPolicy lets a tenant accept backorders for a SKU or for everything
+// a seller sells. A SKU policy takes precedence over a seller policy.
+type BackorderPolicy struct {
+	PolicyID     uuid.UUID `json:"policy_id" bson:"policy_id"`
+	TenantID     uuid.UUID `json:"tenant_id" bson:"tenant_id"`
+	SKUID        uuid.UUID `json:"sku_id,omitempty" bson:"sku_id"`
+	SellerID     uuid.UUID `json:"seller_id,omitempty" bson:"seller_id"`
+	Allowed      bool      `json:"allowed" bson:"allowed"`
+	LeadTimeDays int       `json:"lead_time_days" bson:"lead_time_days" binding:"min=0"`
+	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetter is a Kafka message that could not be handled after all
+// in-consumer attempts, with where it came from and why it failed. It is
+// published to the source topic's dead-letter topic and kept in Mongo for
+// replay.
+type DeadLetter struct {
+	DeadLetterID uuid.UUID         `json:"dead_letter_id" bson:"dead_letter_id"`
+	Topic        string            `json:"topic" bson:"topic"`
+	Partition    int32             `json:"partition" bson:"partition"`
+	Offset       int64             `json:"offset" bson:"offset"`
+	Key          string            `json:"key" bson:"key"`
+	Value        []byte            `json:"value" bson:"value"`
+	Headers      map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
+	Error        string            `json:"error" bson:"error"`
+	Attempts     int               `json:"attempts" bson:"attempts"`
+	FailedAt     time.Time         `json:"failed_at" bson:"failed_at"`
+	ReplayCount  int               `json:"replay_count" bson:"replay_count"`
+	ReplayedAt   *time.Time        `json:"replayed_at,omitempty" bson:"replayed_at,omitempty"`
+}
+
+// DeadLetterFilter selects dead letters to list or replay. Without IDs it
+// matches the ones not replayed yet, oldest first.
+type DeadLetterFilter struct {
+	DeadLetterIDs   []uuid.UUID `json:"dead_letter_ids"`
+	Topic           string      `json:"topic"`
+	IncludeReplayed bool        `json:"include_replayed"`
+	Limit           int         `json:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+type DeadLetterReplayResult struct {
+	Matched  int         `json:"matched"`
+	Replayed int         `json:"replayed"`
+	Failed   []uuid.UUID `json:"failed,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order domain event types. Consumers should ignore types they do not know.
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventOrderCancelled     = "order.cancelled"
+	EventOrderUpdated       = "order.updated"
+	EventOrderShipped       = "order.shipped"
+)
+
+// OrderEventVersion is bumped on breaking changes to the envelope or payload.
+const OrderEventVersion = 1
+
+// OrderEvent is the envelope of every order domain event published to the
+// order events topic, keyed by order ID so each order's events stay in order.
+type OrderEvent struct {
+	EventID    uuid.UUID         `json:"event_id"`
+	Type       string            `json:"type"`
+	Version    int               `json:"version"`
+	TenantID   uuid.UUID         `json:"tenant_id"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Payload    OrderEventPayload `json:"payload"`
+}
+
+// OrderEventPayload is the order after the change. PreviousStatus is set on
+// status changes, cancellations and shipments; ChangedFields lists the fields
+// an order.updated event is about.
+type OrderEventPayload struct {
+	Order          Order    `json:"order"`
+	PreviousStatus string   `json:"previous_status,omitempty"`
+	ChangedFields  []string `json:"changed_fields,omitempty"`
+}
+
+// Shipment records how an order left the hub.
+type Shipment struct {
+	Carrier        string    `json:"carrier,omitempty" bson:"carrier,omitempty"`
+	TrackingNumber string    `json:"tracking_number,omitempty" bson:"tracking_number,omitempty"`
+	ShippedAt      time.Time `json:"shipped_at" bson:"shipped_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FulfilmentOrder is the slice of a parent order that a single hub ships.
+type FulfilmentOrder struct {
+	FulfilmentID  uuid.UUID `json:"fulfilment_id" bson:"fulfilment_id"`
+	ParentOrderID uuid.UUID `json:"parent_order_id" bson:"parent_order_id"`
+	TenantID      uuid.UUID `json:"tenant_id" bson:"tenant_id"`
+	SKUID         uuid.UUID `json:"sku_id" bson:"sku_id"`
+	HubID         uuid.UUID `json:"hub_id" bson:"hub_id"`
+	Quantity      int       `json:"quantity" bson:"quantity"`
+	Status        string    `json:"status" bson:"status"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// HubAvailability is the stock IMS reports for a SKU at one hub.
+type HubAvailability struct {
+	HubID             uuid.UUID `json:"hub_id"`
+	AvailableQuantity int       `json:"available_quantity"`
+	ShippingCost      float64   `json:"shipping_cost"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Hub holds the location data OMS uses to rank a tenant's hubs by distance,
+// and the daily cut-off after which orders ship the next day.
+type Hub struct {
+	HubID         uuid.UUID `json:"hub_id" bson:"hub_id"`
+	TenantID      uuid.UUID `json:"tenant_id" bson:"tenant_id"`
+	Name          string    `json:"name" bson:"name"`
+	Latitude      float64   `json:"latitude" bson:"latitude" binding:"min=-90,max=90"`
+	Longitude     float64   `json:"longitude" bson:"longitude" binding:"min=-180,max=180"`
+	ServedRegions []string  `json:"served_regions,omitempty" bson:"served_regions,omitempty"`
+	CutoffTime    string    `json:"cutoff_time,omitempty" bson:"cutoff_time,omitempty" binding:"omitempty,datetime=15:04"`
+	Timezone      string    `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// PostalCentroid is the approximate location used for addresses without coordinates.
+type PostalCentroid struct {
+	PostalCode string  `json:"postal_code" bson:"postal_code" binding:"required"`
+	Latitude   float64 `json:"latitude" bson:"latitude" binding:"min=-90,max=90"`
+	Longitude  float64 `json:"longitude" bson:"longitude" binding:"min=-180,max=180"`
+}
+
+type ShippingAddress struct {
+	PostalCode string   `json:"postal_code,omitempty" bson:"postal_code,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InventoryUpdate is published by IMS on `inventory.updated` whenever the
+// available quantity of a SKU at a hub changes.
+type InventoryUpdate struct {
+	TenantID          uuid.UUID `json:"tenant_id"`
+	SKUID             uuid.UUID `json:"sku_id"`
+	HubID             uuid.UUID `json:"hub_id"`
+	AvailableQuantity int       `json:"available_quantity"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
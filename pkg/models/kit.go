@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kit is a virtual SKU made up of physical component SKUs known to IMS.
+type Kit struct {
+	TenantID   uuid.UUID      `json:"tenant_id" bson:"tenant_id"`
+	KitSKUID   uuid.UUID      `json:"kit_sku_id" bson:"kit_sku_id" binding:"required"`
+	Name       string         `json:"name" bson:"name"`
+	Components []KitComponent `json:"components" bson:"components" binding:"required,min=1,dive"`
+	UpdatedAt  time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+type KitComponent struct {
+	SKUID    uuid.UUID `json:"sku_id" bson:"sku_id" binding:"required"`
+	Quantity int       `json:"quantity" bson:"quantity" binding:"required,min=1"`
+}
+
+// OrderComponent is a physical line an order for a kit expands into.
+// Quantity is QuantityPerKit times the kit quantity ordered.
+type OrderComponent struct {
+	SKUID          uuid.UUID `json:"sku_id" bson:"sku_id"`
+	QuantityPerKit int       `json:"quantity_per_kit" bson:"quantity_per_kit"`
+	Quantity       int       `json:"quantity" bson:"quantity"`
+}
@@ -9,12 +9,33 @@ import (
 type Order struct {
 	OrderID  uuid.UUID `json:"order_id" csv:"order_id" bson:"order_id"`
 	SKUID    uuid.UUID `json:"sku_id" csv:"sku_id" bson:"sku_id" binding:"required"`
-	HubID    uuid.UUID `json:"hub_id" csv:"hub_id" bson:"hub_id" binding:"required"`
+	HubID    uuid.UUID `json:"hub_id" csv:"hub_id" bson:"hub_id"`
+	// HubRouted is set when OMS picked the hub because the client omitted it;
+	// only routed hubs may be changed by nearest-hub allocation
+	HubRouted bool     `json:"hub_routed,omitempty" bson:"hub_routed,omitempty"`
 	SellerID uuid.UUID `json:"seller_id" csv:"seller_id" bson:"seller_id"`
 	TenantID uuid.UUID    `json:"tenant_id" bson:"tenant_id"`
 	Quantity int       `json:"quantity" csv:"quantity" bson:"quantity"`
 	Price    float64   `json:"price" csv:"price" bson:"price"`
+	Priority int       `json:"priority,omitempty" bson:"priority,omitempty" binding:"min=0"`
+	ShipBy   *time.Time `json:"ship_by,omitempty" bson:"ship_by,omitempty"`
+	SLAStatus string   `json:"sla_status,omitempty" bson:"sla_status,omitempty"`
+	Components []OrderComponent `json:"components,omitempty" bson:"components,omitempty"`
+	DestinationRegion string `json:"destination_region,omitempty" bson:"destination_region,omitempty"`
+	Tags     []string  `json:"tags,omitempty" bson:"tags,omitempty"`
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty" bson:"shipping_address,omitempty"`
 	Status   string    `json:"status" csv:"status" bson:"status"`
+	IsSplit  bool      `json:"is_split" bson:"is_split"`
+	ExpectedAvailableAt *time.Time `json:"expected_available_at,omitempty" bson:"expected_available_at,omitempty"`
+	RetryCount  int        `json:"retry_count,omitempty" bson:"retry_count,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty" bson:"last_attempt_at,omitempty"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty" bson:"next_retry_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	// ReservationAttempt numbers the order's IMS reservations; it moves on
+	// when a reservation is released. Internal to OMS, so not published
+	ReservationAttempt int `json:"-" bson:"reservation_attempt,omitempty"`
+	Shipment    *Shipment  `json:"shipment,omitempty" bson:"shipment,omitempty"`
+	FulfilmentOrders []FulfilmentOrder `json:"fulfilment_orders,omitempty" bson:"-"`
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
 }
\ No newline at end of file
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SKULaunch puts a SKU in pre-order mode until LaunchAt. Orders placed before
+// launch are accepted as pre_order without an inventory check, up to
+// PreorderCap units (0 means no cap).
+type SKULaunch struct {
+	TenantID      uuid.UUID `json:"tenant_id" bson:"tenant_id"`
+	SKUID         uuid.UUID `json:"sku_id" bson:"sku_id" binding:"required"`
+	LaunchAt      time.Time `json:"launch_at" bson:"launch_at" binding:"required"`
+	PreorderCap   int       `json:"preorder_cap" bson:"preorder_cap" binding:"min=0"`
+	PreorderCount int       `json:"preorder_count" bson:"preorder_count"`
+	Released      bool      `json:"released" bson:"released"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
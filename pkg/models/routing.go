@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoutingRule picks or filters hubs for a tenant's orders that arrive without a hub_id.
+// Rules are evaluated in ascending Priority; every non-empty condition must match.
+type RoutingRule struct {
+	RuleID     uuid.UUID         `json:"rule_id" bson:"rule_id"`
+	TenantID   uuid.UUID         `json:"tenant_id" bson:"tenant_id"`
+	Name       string            `json:"name" bson:"name" binding:"required"`
+	Priority   int               `json:"priority" bson:"priority"`
+	Enabled    bool              `json:"enabled" bson:"enabled"`
+	Conditions RoutingConditions `json:"conditions" bson:"conditions"`
+	Action     RoutingAction     `json:"action" bson:"action" binding:"required"`
+	CreatedAt  time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at" bson:"updated_at"`
+}
+
+type RoutingConditions struct {
+	SKUIDs    []uuid.UUID `json:"sku_ids,omitempty" bson:"sku_ids,omitempty"`
+	SellerIDs []uuid.UUID `json:"seller_ids,omitempty" bson:"seller_ids,omitempty"`
+	Regions   []string    `json:"regions,omitempty" bson:"regions,omitempty"`
+	Tags      []string    `json:"tags,omitempty" bson:"tags,omitempty"`
+}
+
+// RoutingAction is one of prefer, exclude, cheapest or nearest. HubIDs is
+// used by prefer and exclude.
+type RoutingAction struct {
+	Type   string      `json:"type" bson:"type" binding:"required,oneof=prefer exclude cheapest nearest"`
+	HubIDs []uuid.UUID `json:"hub_ids,omitempty" bson:"hub_ids,omitempty"`
+}
+
+// HubCandidate is a hub that could ship an order, as seen by the routing engine.
+type HubCandidate struct {
+	HubID             uuid.UUID `json:"hub_id"`
+	AvailableQuantity int       `json:"available_quantity"`
+	ShippingCost      float64   `json:"shipping_cost"`
+	DistanceKm        float64   `json:"distance_km,omitempty"`
+	HasDistance       bool      `json:"-"`
+}
+
+// RoutingDecision is the outcome of routing an order, including the trace
+// returned by the dry-run endpoint.
+type RoutingDecision struct {
+	HubID       uuid.UUID      `json:"hub_id"`
+	RuleID      uuid.UUID      `json:"rule_id,omitempty"`
+	RuleName    string         `json:"rule_name,omitempty"`
+	Action      string         `json:"action"`
+	Explanation []string       `json:"explanation"`
+	Candidates  []HubCandidate `json:"candidates"`
+}
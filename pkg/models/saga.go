@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InventorySaga tracks the reserve -> confirm -> release flow of the stock held
+// for an order (or for one fulfilment order of a split order) so that a
+// reservation is never left behind without an order attached.
+type InventorySaga struct {
+	SagaID       uuid.UUID  `json:"saga_id" bson:"saga_id"`
+	OrderID      uuid.UUID  `json:"order_id" bson:"order_id"`
+	FulfilmentID uuid.UUID  `json:"fulfilment_id" bson:"fulfilment_id"`
+	TenantID     uuid.UUID  `json:"tenant_id" bson:"tenant_id"`
+	Attempt      int        `json:"attempt" bson:"attempt"` // reservation attempt of the order, part of the IMS idempotency keys
+	State        string     `json:"state" bson:"state"`
+	Lines        []SagaLine `json:"lines" bson:"lines"`
+	LastError    string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+type SagaLine struct {
+	SKUID    uuid.UUID `json:"sku_id" bson:"sku_id"`
+	HubID    uuid.UUID `json:"hub_id" bson:"hub_id"`
+	Quantity int       `json:"quantity" bson:"quantity"`
+	Reserved bool      `json:"reserved" bson:"reserved"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SLA states of an order that is close to or past its ship-by deadline.
+const (
+	SLAAtRisk   = "at_risk"
+	SLABreached = "breached"
+)
+
+// SLAPolicy gives each order priority of a tenant the number of days it has
+// to ship, counted in hub cut-offs.
+type SLAPolicy struct {
+	TenantID  uuid.UUID  `json:"tenant_id" bson:"tenant_id"`
+	Levels    []SLALevel `json:"levels" bson:"levels" binding:"required,min=1,dive"`
+	UpdatedAt time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// SLALevel applies to orders of at least Priority.
+type SLALevel struct {
+	Name         string `json:"name" bson:"name"`
+	Priority     int    `json:"priority" bson:"priority" binding:"min=0"`
+	HandlingDays int    `json:"handling_days" bson:"handling_days" binding:"min=0"`
+}
+
+// SLAAlert is sent to the tenant webhook when an order is at risk of missing,
+// or has missed, its ship-by deadline.
+type SLAAlert struct {
+	Event     string    `json:"event"`
+	OrderID   uuid.UUID `json:"order_id"`
+	TenantID  uuid.UUID `json:"tenant_id"`
+	Status    string    `json:"status"`
+	Priority  int       `json:"priority"`
+	ShipBy    time.Time `json:"ship_by"`
+	SLAStatus string    `json:"sla_status"`
+}
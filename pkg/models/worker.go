@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkerStatus describes a background worker as seen by the replica that
+// answers the request. Only the leader runs the worker, so run times and
+// counts come from the leader's view.
+type WorkerStatus struct {
+	Name           string     `json:"name"`
+	Interval       string     `json:"interval"`
+	Paused         bool       `json:"paused"`
+	Running        bool       `json:"running"`
+	Leader         bool       `json:"leader"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDuration   string     `json:"last_duration,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	LastProcessed  int        `json:"last_processed"`
+	TotalProcessed int64      `json:"total_processed"`
+}
+
+// RetryFilter selects held or failed orders to retry on demand. Empty fields
+// match every order.
+type RetryFilter struct {
+	TenantID      uuid.UUID  `json:"tenant_id"`
+	SellerID      uuid.UUID  `json:"seller_id"`
+	SKUID         uuid.UUID  `json:"sku_id"`
+	HubID         uuid.UUID  `json:"hub_id"`
+	Status        string     `json:"status" binding:"omitempty,oneof=on_hold partially_allocated backordered failed"`
+	CreatedBefore *time.Time `json:"created_before"`
+	Limit         int        `json:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+// RetryResult reports the outcome of an on-demand retry.
+type RetryResult struct {
+	Matched int            `json:"matched"`
+	Retried int            `json:"retried"`
+	Orders  []RetryOutcome `json:"orders"`
+}
+
+type RetryOutcome struct {
+	OrderID   uuid.UUID `json:"order_id"`
+	Status    string    `json:"status"`
+	LastError string    `json:"last_error,omitempty"`
+}
@@ -12,10 +12,56 @@ func InitServer(server *http.Server) {
 	server.POST("/orders/bulkorder", controllers.CreateBulkOrder)
 	server.POST("/orders", controllers.CreateOrder)
 	server.GET("/orders", controllers.GetOrders)
+	server.POST("/orders/:order_id/cancel", controllers.CancelOrder)
+	server.POST("/orders/:order_id/ship", controllers.ShipOrder)
+
+	// Routing Routes
+	server.POST("/routing/rules", controllers.CreateRoutingRule)
+	server.GET("/routing/rules", controllers.GetRoutingRules)
+	server.DELETE("/routing/rules/:rule_id", controllers.DeleteRoutingRule)
+	server.POST("/routing/dry-run", controllers.DryRunRouting)
+
+	// Hub Routes
+	server.GET("/hubs", controllers.GetHubLocations)
+	server.PUT("/hubs/:hub_id/location", controllers.UpsertHubLocation)
+	server.POST("/hubs/postal-centroids", controllers.UpsertPostalCentroids)
+
+	// Backorder Routes
+	server.POST("/backorders/policies", controllers.UpsertBackorderPolicy)
+	server.GET("/backorders/policies", controllers.GetBackorderPolicies)
+
+	// Pre-order Routes
+	server.POST("/preorders/launches", controllers.UpsertSKULaunch)
+	server.GET("/preorders/launches", controllers.GetSKULaunches)
+
+	// Allocation Routes
+	server.PUT("/allocation/policy", controllers.UpsertAllocationPolicy)
+	server.GET("/allocation/policy", controllers.GetAllocationPolicy)
+	server.PUT("/sla/policy", controllers.UpsertSLAPolicy)
+	server.GET("/sla/policy", controllers.GetSLAPolicy)
+
+	// Kit Routes
+	server.POST("/kits", controllers.UpsertKit)
+	server.GET("/kits", controllers.GetKits)
 
 	// Webhook Routes
 	server.POST("webhooks/register", controllers.RegisterWebhook)
 
+	// Health Routes
+	server.GET("/health", controllers.Health)
+
+	// Admin Routes
+	server.POST("/admin/validation-cache/invalidate", controllers.InvalidateValidationCache)
+	server.GET("/admin/validation-cache/stats", controllers.GetValidationCacheStats)
+	server.GET("/admin/workers", controllers.ListWorkers)
+	server.POST("/admin/workers/:name/pause", controllers.PauseWorker)
+	server.POST("/admin/workers/:name/resume", controllers.ResumeWorker)
+	server.POST("/admin/workers/:name/trigger", controllers.TriggerWorker)
+	server.POST("/admin/orders/retry", controllers.RetryOrders)
+	server.POST("/admin/orders/:order_id/retry", controllers.RetryOrder)
+	server.GET("/admin/dlq", controllers.ListDeadLetters)
+	server.POST("/admin/dlq/replay", controllers.ReplayDeadLetters)
+
 	// Swagger Routes
 	server.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
\ No newline at end of file
@@ -0,0 +1,157 @@
+package schemas
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CheckCompatibility lists the changes from old to next that would break
+// consumers built against old, or consumers built against next reading
+// messages still published with old. A compatible change only adds
+// optional fields and messages, or removes optional fields while reserving
+// their proto number and name.
+func CheckCompatibility(old, next *Set) []string {
+	var problems []string
+	problems = append(problems, checkProtoCompatibility(old.proto, next.proto)...)
+	problems = append(problems, checkJSONCompatibility(old.defs, next.defs)...)
+	return problems
+}
+
+func checkProtoCompatibility(old, next protoreflect.FileDescriptor) []string {
+	var problems []string
+	if old.Package() != next.Package() {
+		problems = append(problems, fmt.Sprintf("proto package changed from %s to %s", old.Package(), next.Package()))
+	}
+
+	oldMessages := old.Messages()
+	for i := 0; i < oldMessages.Len(); i++ {
+		oldMsg := oldMessages.Get(i)
+		newMsg := next.Messages().ByName(oldMsg.Name())
+		if newMsg == nil {
+			problems = append(problems, fmt.Sprintf("proto message %s was removed", oldMsg.Name()))
+			continue
+		}
+
+		oldFields := oldMsg.Fields()
+		for j := 0; j < oldFields.Len(); j++ {
+			oldField := oldFields.Get(j)
+			name := fmt.Sprintf("%s.%s", oldMsg.Name(), oldField.Name())
+			newField := newMsg.Fields().ByNumber(oldField.Number())
+			if newField == nil {
+				if !newMsg.ReservedRanges().Has(oldField.Number()) || !newMsg.ReservedNames().Has(oldField.Name()) {
+					problems = append(problems, fmt.Sprintf("proto field %s (%d) was removed without reserving its number and name", name, oldField.Number()))
+				}
+				continue
+			}
+			if newField.Name() != oldField.Name() {
+				problems = append(problems, fmt.Sprintf("proto field %d of %s was renamed from %s to %s", oldField.Number(), oldMsg.Name(), oldField.Name(), newField.Name()))
+			}
+			if describeField(newField) != describeField(oldField) {
+				problems = append(problems, fmt.Sprintf("proto field %s changed type from %s to %s", name, describeField(oldField), describeField(newField)))
+			}
+		}
+
+		newFields := newMsg.Fields()
+		for j := 0; j < newFields.Len(); j++ {
+			newField := newFields.Get(j)
+			if oldMsg.ReservedRanges().Has(newField.Number()) || oldMsg.ReservedNames().Has(newField.Name()) {
+				problems = append(problems, fmt.Sprintf("proto field %s.%s reuses a reserved number or name", newMsg.Name(), newField.Name()))
+			}
+		}
+		oldRanges := oldMsg.ReservedRanges()
+		for j := 0; j < oldRanges.Len(); j++ {
+			if span := oldRanges.Get(j); !reservesRange(newMsg.ReservedRanges(), span) {
+				problems = append(problems, fmt.Sprintf("proto message %s no longer reserves field %d", oldMsg.Name(), span[0]))
+			}
+		}
+		oldNames := oldMsg.ReservedNames()
+		for j := 0; j < oldNames.Len(); j++ {
+			if name := oldNames.Get(j); !newMsg.ReservedNames().Has(name) {
+				problems = append(problems, fmt.Sprintf("proto message %s no longer reserves field name %s", oldMsg.Name(), name))
+			}
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// reservesRange reports whether one of ranges covers all of span.
+func reservesRange(ranges protoreflect.FieldRanges, span [2]protoreflect.FieldNumber) bool {
+	for i := 0; i < ranges.Len(); i++ {
+		if r := ranges.Get(i); r[0] <= span[0] && span[1] <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func describeField(field protoreflect.FieldDescriptor) string {
+	typ := field.Kind().String()
+	if field.Message() != nil {
+		typ = string(field.Message().Name())
+	}
+	if field.IsList() {
+		return "repeated " + typ
+	}
+	return typ
+}
+
+func checkJSONCompatibility(old, next map[string]*jsonschema.Schema) []string {
+	var problems []string
+
+	for name, oldDef := range old {
+		newDef, ok := next[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("JSON schema definition %s was removed", name))
+			continue
+		}
+
+		oldRequired := make(map[string]bool, len(oldDef.Required))
+		for _, prop := range oldDef.Required {
+			oldRequired[prop] = true
+		}
+		newRequired := make(map[string]bool, len(newDef.Required))
+		for _, prop := range newDef.Required {
+			newRequired[prop] = true
+			if !oldRequired[prop] {
+				problems = append(problems, fmt.Sprintf("JSON schema property %s.%s became required", name, prop))
+			}
+		}
+
+		for prop, oldProp := range oldDef.Properties {
+			newProp, ok := newDef.Properties[prop]
+			switch {
+			case !ok && oldRequired[prop]:
+				problems = append(problems, fmt.Sprintf("required JSON schema property %s.%s was removed", name, prop))
+			case !ok:
+			case !newRequired[prop] && oldRequired[prop]:
+				problems = append(problems, fmt.Sprintf("JSON schema property %s.%s is no longer required", name, prop))
+			}
+			if ok && describeJSON(oldProp) != describeJSON(newProp) {
+				problems = append(problems, fmt.Sprintf("JSON schema property %s.%s changed type from %s to %s", name, prop, describeJSON(oldProp), describeJSON(newProp)))
+			}
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// describeJSON is the type of a property including its format, which
+// consumers rely on as much as the type itself.
+func describeJSON(schema *jsonschema.Schema) string {
+	switch {
+	case schema.Ref != nil:
+		return jsonType(schema)
+	case schema.Items2020 != nil:
+		return "[]" + describeJSON(schema.Items2020)
+	case schema.Format != "":
+		return jsonType(schema) + " (" + schema.Format + ")"
+	default:
+		return jsonType(schema)
+	}
+}
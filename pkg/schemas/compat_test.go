@@ -0,0 +1,174 @@
+package schemas
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// released lists the schema versions consumers may have been built
+// against. Copy the schemas into a new testdata directory when releasing a
+// change, and bump models.OrderEventVersion for a deliberate breaking one.
+var released = []string{"v1"}
+
+// compile parses proto sources the way protoc does, for schema versions
+// that have no generated code.
+func compile(t *testing.T, resolver protocompile.Resolver, name string) protoreflect.FileDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{Resolver: resolver}
+	files, err := compiler.Compile(context.Background(), name)
+	if err != nil {
+		t.Fatalf("compile %s: %v", name, err)
+	}
+	return files[0]
+}
+
+func loadSource(t *testing.T, protoSrc, jsonSrc string) (*Set, error) {
+	t.Helper()
+	resolver := &protocompile.SourceResolver{
+		Accessor: protocompile.SourceAccessorFromMap(map[string]string{"test.proto": protoSrc}),
+	}
+	return Load(compile(t, resolver, "test.proto"), []byte(jsonSrc))
+}
+
+func loadReleased(t *testing.T, version string) *Set {
+	t.Helper()
+	dir := filepath.Join("testdata", version)
+	jsonSrc, err := os.ReadFile(filepath.Join(dir, "order_events.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto := compile(t, &protocompile.SourceResolver{ImportPaths: []string{dir}}, "order_events.proto")
+	set, err := Load(proto, jsonSrc)
+	if err != nil {
+		t.Fatalf("load %s: %v", version, err)
+	}
+	return set
+}
+
+func TestSchemasBackwardCompatible(t *testing.T) {
+	for _, version := range released {
+		t.Run(version, func(t *testing.T) {
+			current, err := Current()
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems := CheckCompatibility(loadReleased(t, version), current)
+			for _, problem := range problems {
+				t.Errorf("incompatible with %s: %s", version, problem)
+			}
+		})
+	}
+}
+
+const (
+	baseProto = `syntax = "proto3";
+package oms.events.v1;
+message A {
+  string id = 1;
+  int64 count = 2;
+  optional string note = 3;
+  repeated string tags = 4;
+}`
+	baseJSON = `{"$defs": {"A": {"type": "object", "required": ["id", "count"], "properties": {
+  "id": {"type": "string", "format": "uuid"},
+  "count": {"type": "integer"},
+  "note": {"type": "string"},
+  "tags": {"type": "array", "items": {"type": "string"}}
+}}}}`
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		proto     string
+		json      string
+		wantIssue string
+	}{
+		{
+			name:  "Optional Field Added",
+			proto: strings.Replace(baseProto, "}", "  string carrier = 5;\n}", 1),
+			json:  strings.Replace(baseJSON, `"note":`, `"carrier": {"type": "string"}, "note":`, 1),
+		},
+		{
+			name:  "Optional Field Removed And Reserved",
+			proto: strings.Replace(baseProto, "optional string note = 3;", `reserved 3; reserved "note";`, 1),
+			json:  strings.Replace(baseJSON, `"note": {"type": "string"},`, "", 1),
+		},
+		{
+			name:      "Field Removed Without Reserving",
+			proto:     strings.Replace(baseProto, "optional string note = 3;", "", 1),
+			json:      strings.Replace(baseJSON, `"note": {"type": "string"},`, "", 1),
+			wantIssue: "proto field A.note (3) was removed without reserving its number and name",
+		},
+		{
+			name:      "Required Field Removed",
+			proto:     strings.Replace(baseProto, "int64 count = 2;", `reserved 2; reserved "count";`, 1),
+			json:      strings.Replace(strings.Replace(baseJSON, `"count": {"type": "integer"},`, "", 1), `, "count"]`, "]", 1),
+			wantIssue: "required JSON schema property A.count was removed",
+		},
+		{
+			name:      "Field Type Changed",
+			proto:     strings.Replace(baseProto, "int64 count", "string count", 1),
+			json:      strings.Replace(baseJSON, `"count": {"type": "integer"}`, `"count": {"type": "string"}`, 1),
+			wantIssue: "proto field A.count changed type from int64 to string",
+		},
+		{
+			name:      "Format Changed",
+			proto:     baseProto,
+			json:      strings.Replace(baseJSON, `"format": "uuid"`, `"format": "date-time"`, 1),
+			wantIssue: "JSON schema property A.id changed type from string (uuid) to string (date-time)",
+		},
+		{
+			name:      "Field Renamed",
+			proto:     strings.Replace(baseProto, "string id = 1", "string order_id = 1", 1),
+			json:      strings.Replace(strings.Replace(baseJSON, `"id": {`, `"order_id": {`, 1), `["id"`, `["order_id"`, 1),
+			wantIssue: "proto field 1 of A was renamed from id to order_id",
+		},
+		{
+			name:      "Field Became Required",
+			proto:     baseProto,
+			json:      strings.Replace(baseJSON, `["id", "count"]`, `["id", "count", "note"]`, 1),
+			wantIssue: "JSON schema property A.note became required",
+		},
+		{
+			name:      "Field Made Repeated",
+			proto:     strings.Replace(baseProto, "int64 count", "repeated int64 count", 1),
+			json:      strings.Replace(baseJSON, `"count": {"type": "integer"}`, `"count": {"type": "array", "items": {"type": "integer"}}`, 1),
+			wantIssue: "proto field A.count changed type from int64 to repeated int64",
+		},
+	}
+
+	base, err := loadSource(t, baseProto, baseJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			changed, err := loadSource(t, tc.proto, tc.json)
+			if err != nil {
+				t.Fatalf("load changed schemas: %v", err)
+			}
+
+			problems := CheckCompatibility(base, changed)
+			if tc.wantIssue == "" {
+				if len(problems) > 0 {
+					t.Fatalf("expected compatible, got %v", problems)
+				}
+				return
+			}
+			for _, problem := range problems {
+				if problem == tc.wantIssue {
+					return
+				}
+			}
+			t.Fatalf("expected %q among %v", tc.wantIssue, problems)
+		})
+	}
+}
@@ -0,0 +1,296 @@
+// Package schemas holds the schemas of the messages OMS publishes to Kafka,
+// as protobuf (order_events.proto, generated into eventspb) and as JSON
+// Schema (order_events.schema.json), and validates messages with them.
+package schemas
+
+//go:generate protoc --go_out=eventspb --go_opt=paths=source_relative order_events.proto
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas/eventspb"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Messages described by the schemas.
+const (
+	Order      = "Order"
+	OrderEvent = "OrderEvent"
+)
+
+// ErrInvalid is returned for a message that does not match its schema.
+var ErrInvalid = errors.New("message does not match its schema")
+
+//go:embed order_events.schema.json
+var jsonSource []byte
+
+var (
+	loadOnce sync.Once
+	current  *Set
+	loadErr  error
+)
+
+// Set is one version of the schemas: the protobuf file and the JSON Schema
+// describing the same messages.
+type Set struct {
+	proto protoreflect.FileDescriptor
+	defs  map[string]*jsonschema.Schema
+}
+
+// Load compiles a version of the JSON Schema and checks that it describes
+// the same messages and fields as the protobuf file.
+func Load(proto protoreflect.FileDescriptor, jsonSrc []byte) (*Set, error) {
+	var doc struct {
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(jsonSrc, &doc); err != nil {
+		return nil, fmt.Errorf("parse JSON schema: %w", err)
+	}
+
+	const url = "order_events.schema.json"
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	if err := compiler.AddResource(url, bytes.NewReader(jsonSrc)); err != nil {
+		return nil, fmt.Errorf("parse JSON schema: %w", err)
+	}
+
+	set := &Set{proto: proto, defs: make(map[string]*jsonschema.Schema, len(doc.Defs))}
+	for name := range doc.Defs {
+		schema, err := compiler.Compile(url + "#/$defs/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("compile JSON schema %s: %w", name, err)
+		}
+		set.defs[name] = schema
+	}
+
+	if err := set.checkConsistent(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Current is the version of the schemas OMS publishes with. It is loaded on
+// first use, so a broken schema fails the messages instead of the process.
+func Current() (*Set, error) {
+	loadOnce.Do(func() {
+		current, loadErr = Load(eventspb.File_order_events_proto, jsonSource)
+	})
+	return current, loadErr
+}
+
+// Validate checks a consumed JSON message against the current schema.
+func Validate(message string, data []byte) error {
+	set, err := Current()
+	if err != nil {
+		return err
+	}
+	return set.Validate(message, data)
+}
+
+// ValidateStrict checks a JSON message about to be published against the
+// current schema.
+func ValidateStrict(message string, data []byte) error {
+	set, err := Current()
+	if err != nil {
+		return err
+	}
+	return set.ValidateStrict(message, data)
+}
+
+// ValidateProto checks a consumed protobuf message against the current
+// schema.
+func ValidateProto(msg proto.Message) error {
+	set, err := Current()
+	if err != nil {
+		return err
+	}
+	return set.ValidateProto(msg)
+}
+
+// Validate checks a JSON message against its JSON Schema definition.
+// Properties the schema does not know are allowed, so consumers accept
+// messages from newer producers.
+func (s *Set) Validate(message string, data []byte) error {
+	schema, ok := s.defs[message]
+	if !ok {
+		return fmt.Errorf("no schema for message %s", message)
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := schema.Validate(value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+// ValidateStrict is Validate that also rejects properties missing from the
+// protobuf message, so a model field the schemas do not cover is caught
+// before it is published.
+func (s *Set) ValidateStrict(message string, data []byte) error {
+	if err := s.Validate(message, data); err != nil {
+		return err
+	}
+
+	desc := s.proto.Messages().ByName(protoreflect.Name(message))
+	if err := protojson.Unmarshal(data, dynamicpb.NewMessage(desc)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+// ValidateProto checks a decoded protobuf message against the JSON Schema
+// definition of the same message, so both encodings accept the same
+// messages. proto3 cannot tell a field left unset from one set to its zero
+// value, so only unset optional fields and messages count as missing.
+func (s *Set) ValidateProto(msg proto.Message) error {
+	m := msg.ProtoReflect()
+	name := string(m.Descriptor().Name())
+	schema, ok := s.defs[name]
+	if !ok {
+		return fmt.Errorf("no schema for message %s", name)
+	}
+	if err := schema.Validate(protoValue(m)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+// protoValue is a protobuf message as the JSON Schema library sees decoded
+// JSON. Fields the descriptor does not know are left out.
+func protoValue(m protoreflect.Message) map[string]interface{} {
+	value := make(map[string]interface{})
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.HasPresence() && !m.Has(field) {
+			continue
+		}
+
+		if field.IsList() {
+			list := m.Get(field).List()
+			items := make([]interface{}, list.Len())
+			for j := range items {
+				items[j] = protoFieldValue(field, list.Get(j))
+			}
+			value[string(field.Name())] = items
+			continue
+		}
+		value[string(field.Name())] = protoFieldValue(field, m.Get(field))
+	}
+	return value
+}
+
+func protoFieldValue(field protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoValue(v.Message())
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		if value := field.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return json.Number(strconv.FormatInt(int64(v.Enum()), 10))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return json.Number(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return json.Number(strconv.FormatUint(v.Uint(), 10))
+	default:
+		return json.Number(strconv.FormatInt(v.Int(), 10))
+	}
+}
+
+// checkConsistent makes sure every protobuf message has a JSON Schema
+// definition with the same fields and matching types, so a message means
+// the same in both encodings.
+func (s *Set) checkConsistent() error {
+	messages := s.proto.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		msg := messages.Get(i)
+		def, ok := s.defs[string(msg.Name())]
+		if !ok {
+			return fmt.Errorf("message %s has no JSON schema definition", msg.Name())
+		}
+
+		fields := msg.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			field := fields.Get(j)
+			prop, ok := def.Properties[string(field.Name())]
+			if !ok {
+				return fmt.Errorf("field %s.%s has no JSON schema property", msg.Name(), field.Name())
+			}
+			if want, got := protoType(field), jsonType(prop); want != got {
+				return fmt.Errorf("field %s.%s is %s in proto but %s in JSON schema", msg.Name(), field.Name(), want, got)
+			}
+		}
+		for name := range def.Properties {
+			if fields.ByName(protoreflect.Name(name)) == nil {
+				return fmt.Errorf("JSON schema property %s.%s has no proto field", msg.Name(), name)
+			}
+		}
+	}
+
+	for name := range s.defs {
+		if messages.ByName(protoreflect.Name(name)) == nil {
+			return fmt.Errorf("JSON schema definition %s has no proto message", name)
+		}
+	}
+	return nil
+}
+
+// protoType is the JSON type a protobuf field is encoded as, with messages
+// named after their JSON Schema definition.
+func protoType(field protoreflect.FieldDescriptor) string {
+	var typ string
+	switch field.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+		typ = "string"
+	case protoreflect.BoolKind:
+		typ = "boolean"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		typ = "number"
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		typ = string(field.Message().Name())
+	default:
+		typ = "integer"
+	}
+	if field.IsList() {
+		return "[]" + typ
+	}
+	return typ
+}
+
+// jsonType is the type of a JSON Schema property, with references named
+// after the definition they point to.
+func jsonType(schema *jsonschema.Schema) string {
+	switch {
+	case schema.Ref != nil:
+		return schema.Ref.Location[strings.LastIndex(schema.Ref.Location, "/")+1:]
+	case len(schema.Types) == 1 && schema.Types[0] == "array" && schema.Items2020 != nil:
+		return "[]" + jsonType(schema.Items2020)
+	default:
+		return strings.Join(schema.Types, "|")
+	}
+}
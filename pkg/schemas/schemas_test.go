@@ -0,0 +1,121 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validOrder = `{
+	"order_id": "6f1c2f8e-4a7b-4d7e-9a43-1f0c8b9e2a11",
+	"sku_id": "0b6c7a52-93e8-4f0e-8b44-6c1f7c0a9d20",
+	"hub_id": "00000000-0000-0000-0000-000000000000",
+	"seller_id": "a3d9b7c1-5e2f-4a6b-8c0d-1e2f3a4b5c6d",
+	"tenant_id": "b4e0c8d2-6f3a-4b7c-9d1e-2f3a4b5c6d7e",
+	"quantity": 3,
+	"price": 19.5,
+	"status": "new_order",
+	"is_split": false,
+	"tags": ["gift"],
+	"shipping_address": {"postal_code": "560001", "latitude": 0, "longitude": 77.59},
+	"components": [{"sku_id": "0b6c7a52-93e8-4f0e-8b44-6c1f7c0a9d20", "quantity_per_kit": 1, "quantity": 3}],
+	"ship_by": "2026-10-20T18:00:00Z",
+	"created_at": "2026-10-19T09:30:00.123456789Z",
+	"updated_at": "2026-10-19T09:30:00Z"
+}`
+
+func withProperty(t *testing.T, src, name string, value interface{}) []byte {
+	t.Helper()
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(src), &object); err != nil {
+		t.Fatal(err)
+	}
+	if value == nil {
+		delete(object, name)
+	} else {
+		object[name] = value
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCurrentSchemasLoad(t *testing.T) {
+	if _, err := Current(); err != nil {
+		t.Fatalf("current schemas do not load: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		strict  bool
+		wantErr string
+	}{
+		{name: "Valid Order", data: []byte(validOrder)},
+		{name: "Missing Required Property", data: withProperty(t, validOrder, "order_id", nil), wantErr: "missing properties: 'order_id'"},
+		{name: "Wrong Type", data: withProperty(t, validOrder, "quantity", "3"), wantErr: "expected integer, but got string"},
+		{name: "Fractional Integer", data: withProperty(t, validOrder, "quantity", 2.5), wantErr: "expected integer, but got number"},
+		{name: "Bad UUID", data: withProperty(t, validOrder, "sku_id", "sku-1"), wantErr: "'sku-1' is not valid 'uuid'"},
+		{name: "Bad Date-Time", data: withProperty(t, validOrder, "created_at", "yesterday"), wantErr: "'yesterday' is not valid 'date-time'"},
+		{name: "Nested Definition", data: withProperty(t, validOrder, "components", []interface{}{map[string]interface{}{"sku_id": "x"}}), wantErr: "missing properties: 'quantity_per_kit', 'quantity'"},
+		{name: "Unknown Property Tolerated", data: withProperty(t, validOrder, "gift_note", "hi")},
+		{name: "Unknown Property Rejected When Strict", data: withProperty(t, validOrder, "gift_note", "hi"), strict: true, wantErr: `unknown field "gift_note"`},
+		{name: "Not JSON", data: []byte("{"), wantErr: "does not match"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			validate := Validate
+			if tc.strict {
+				validate = ValidateStrict
+			}
+
+			err := validate(Order, tc.data)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsSchemasThatDisagree(t *testing.T) {
+	tests := []struct {
+		name    string
+		proto   string
+		json    string
+		wantErr string
+	}{
+		{
+			name:    "Field Missing From JSON Schema",
+			proto:   `syntax = "proto3"; message A { string id = 1; int64 count = 2; }`,
+			json:    `{"$defs": {"A": {"type": "object", "properties": {"id": {"type": "string"}}}}}`,
+			wantErr: "field A.count has no JSON schema property",
+		},
+		{
+			name:    "Type Mismatch",
+			proto:   `syntax = "proto3"; message A { string id = 1; }`,
+			json:    `{"$defs": {"A": {"type": "object", "properties": {"id": {"type": "integer"}}}}}`,
+			wantErr: "field A.id is string in proto but integer in JSON schema",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadSource(t, tc.proto, tc.json)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -2,41 +2,206 @@ package services
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/config"
 	"github.com/omniful/go_commons/i18n"
 	"github.com/omniful/go_commons/log"
 )
 
-func StartOrderRetryWorker() {
-	ctx := context.Background()
-	go func() {
-		ticker := time.NewTicker(2 * time.Minute) 
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				log.Info(i18n.Translate(ctx, "Running retry logic for on_hold orders"))
-				processOnHoldOrders()
+// retrySettings configure one run of the retry worker.
+type retrySettings struct {
+	pageSize     int
+	workers      int
+	orderTimeout time.Duration
+	runTimeout   time.Duration
+}
+
+func loadRetrySettings(ctx context.Context, interval time.Duration) retrySettings {
+	settings := retrySettings{
+		pageSize:     config.GetInt(ctx, "retry.page_size"),
+		workers:      config.GetInt(ctx, "retry.workers"),
+		orderTimeout: config.GetDuration(ctx, "retry.order_timeout"),
+		runTimeout:   config.GetDuration(ctx, "retry.run_timeout"),
+	}
+	if settings.pageSize <= 0 {
+		settings.pageSize = 200
+	}
+	if settings.workers <= 0 {
+		settings.workers = 8
+	}
+	if settings.orderTimeout <= 0 {
+		settings.orderTimeout = 30 * time.Second
+	}
+	if settings.runTimeout <= 0 || settings.runTimeout > interval {
+		settings.runTimeout = interval
+	}
+	return settings
+}
+
+// StartOrderRetryWorker periodically retries the held orders whose next
+// retry is due. Each failed attempt pushes the next one back exponentially;
+// stock changes are normally picked up from `inventory.updated` instead. Only
+// the replica holding the `order-retry` lease runs it.
+//
+// A run never outlasts `retry.run_timeout` (at most the interval), and the
+// next run starts a full interval after the previous one finished, so runs
+// never overlap or pile up. Orders left over are still due on the next run.
+func StartOrderRetryWorker(ctx context.Context) {
+	interval := config.GetDuration(ctx, "retry.interval")
+	if interval <= 0 {
+		interval = 2 * time.Minute
+	}
+	settings := loadRetrySettings(ctx, interval)
+
+	if err := helpers.EnsureRetryIndex(ctx); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to create retry index: %v"), err)
+	}
+
+	StartWorker(ctx, "order-retry", interval, false, func(ctx context.Context) (int, error) {
+		return processOnHoldOrders(ctx, settings)
+	})
+}
+
+// retryAndNotify retries a held order and tells the tenant when it failed
+// for good.
+func retryAndNotify(backoff helpers.OrderBackoff) func(context.Context, models.Order) models.Order {
+	return func(ctx context.Context, order models.Order) models.Order {
+		updated := helpers.RetryHeldOrder(ctx, order, backoff)
+		if updated.Status == "failed" {
+			// The order has failed for good even if its retry deadline passed
+			NotifyTenantWebhook(context.WithoutCancel(ctx), updated.TenantID.String(), updated)
+		}
+		return updated
+	}
+}
+
+func processOnHoldOrders(parent context.Context, settings retrySettings) (int, error) {
+	ctx, cancel := context.WithTimeout(parent, settings.runTimeout)
+	defer cancel()
+
+	// Retried per tenant in allocation policy order, stopping per SKU/hub at the first order that cannot be filled
+	retry := retryAndNotify(helpers.LoadOrderBackoff(ctx))
+	allocator := helpers.NewHeldOrderAllocator(helpers.RealAllocationPolicyStore{}, retry, settings.workers, settings.orderTimeout)
+
+	started := time.Now()
+	seen := make(map[uuid.UUID]bool)
+	fetched, retried := 0, 0
+	err := helpers.StreamOrdersDueForRetry(ctx, started, settings.pageSize, func(page []models.Order) error {
+		// Orders updated during the run can show up on the cursor again
+		fresh := page[:0]
+		for _, order := range page {
+			if !seen[order.OrderID] {
+				seen[order.OrderID] = true
+				fresh = append(fresh, order)
 			}
 		}
-	}()
+
+		// A former leader stops before its successor's run retries the same orders
+		if err := helpers.CheckFence(ctx); err != nil {
+			return err
+		}
+
+		fetched += len(fresh)
+		retried += allocator.Allocate(ctx, fresh)
+		return ctx.Err()
+	})
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		log.Warnf(i18n.Translate(ctx, "Retry run hit its %s limit; remaining orders wait for the next run"), settings.runTimeout)
+		err = nil
+	case parent.Err() != nil:
+		log.Warnf(i18n.Translate(ctx, "Retry run stopped: %v"), context.Cause(parent))
+	case errors.Is(err, helpers.ErrStaleLeader):
+		log.Warnf(i18n.Translate(ctx, "Retry run stopped: %v"), err)
+	case err != nil:
+		log.Errorf(i18n.Translate(ctx, "Failed to fetch on_hold orders: %v"), err)
+	}
+
+	log.Infof(i18n.Translate(ctx, "Retried %d of %d held orders due for retry in %s"), retried, fetched, time.Since(started))
+	return retried, err
+}
+
+// OrderRetrier retries held or failed orders on demand, whether or not
+// their next retry is due.
+type OrderRetrier interface {
+	RetryOrder(ctx context.Context, orderID uuid.UUID) (models.Order, error)
+	RetryOrders(ctx context.Context, filter models.RetryFilter) (models.RetryResult, error)
 }
 
-func processOnHoldOrders() {
-	ctx := context.Background()
+type RealOrderRetrier struct{}
 
-	orders, err := helpers.GetOnHoldOrders(ctx)
+// prepareManualRetry reopens failed orders and rejects orders that are not
+// waiting for stock.
+func prepareManualRetry(ctx context.Context, order models.Order) (models.Order, error) {
+	if order.Status == "failed" {
+		return helpers.ReopenFailedOrder(ctx, order)
+	}
+	if !helpers.IsWaitingForStock(order.Status) {
+		return order, helpers.ErrOrderNotRetryable
+	}
+	return order, nil
+}
+
+func (RealOrderRetrier) RetryOrder(ctx context.Context, orderID uuid.UUID) (models.Order, error) {
+	order, err := helpers.GetOrder(ctx, orderID)
 	if err != nil {
-		log.Errorf(i18n.Translate(ctx, "Failed to fetch on_hold orders: %v"), err)
-		return
+		return order, err
 	}
+	order, err = prepareManualRetry(ctx, order)
+	if err != nil {
+		return order, err
+	}
+
+	settings := loadRetrySettings(ctx, time.Hour)
+	orderCtx, cancel := context.WithTimeout(ctx, settings.orderTimeout)
+	defer cancel()
+
+	log.Infof(i18n.Translate(ctx, "Retrying order %s on demand"), orderID)
+	return retryAndNotify(helpers.LoadOrderBackoff(ctx))(orderCtx, order), nil
+}
 
+// RetryOrders retries the matching orders through the same allocation rules
+// as the retry worker.
+func (RealOrderRetrier) RetryOrders(ctx context.Context, filter models.RetryFilter) (models.RetryResult, error) {
+	orders, err := helpers.FindOrdersForRetry(ctx, filter)
+	if err != nil {
+		return models.RetryResult{}, err
+	}
+
+	held := make([]models.Order, 0, len(orders))
 	for _, order := range orders {
-		log.Infof(i18n.Translate(ctx, "Retrying order: %s"), order.OrderID)
+		order, err := prepareManualRetry(ctx, order)
+		if err != nil {
+			log.Warnf(i18n.Translate(ctx, "Skipping on-demand retry of order %s: %v"), order.OrderID, err)
+			continue
+		}
+		held = append(held, order)
+	}
 
-		helpers.CheckAndUpdateOrder(ctx, order)
+	var (
+		mu       sync.Mutex
+		outcomes []models.RetryOutcome
+	)
+	retry := retryAndNotify(helpers.LoadOrderBackoff(ctx))
+	record := func(ctx context.Context, order models.Order) models.Order {
+		updated := retry(ctx, order)
+		mu.Lock()
+		outcomes = append(outcomes, models.RetryOutcome{OrderID: updated.OrderID, Status: updated.Status, LastError: updated.LastError})
+		mu.Unlock()
+		return updated
 	}
+
+	settings := loadRetrySettings(ctx, time.Hour)
+	allocator := helpers.NewHeldOrderAllocator(helpers.RealAllocationPolicyStore{}, record, settings.workers, settings.orderTimeout)
+	retried := allocator.Allocate(ctx, held)
+
+	log.Infof(i18n.Translate(ctx, "Retried %d of %d matching orders on demand"), retried, len(orders))
+	return models.RetryResult{Matched: len(orders), Retried: retried, Orders: outcomes}, nil
 }
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+// deadLetterHandler retries a message a few times in the consumer and then
+// publishes it with the error to the topic's dead-letter topic, so a poison
+// message neither blocks its partition nor gets lost.
+type deadLetterHandler struct {
+	next pubsub.IPubSubMessageHandler
+}
+
+func (h deadLetterHandler) Process(ctx context.Context, msg *pubsub.Message) error {
+	attempts, err := helpers.HandleWithRetry(ctx, kafkaConfig.DLQ.MaxAttempts, kafkaConfig.DLQ.RetryBackoff, func(ctx context.Context) error {
+		return h.next.Process(ctx, msg)
+	})
+	if err == nil {
+		return nil
+	}
+
+	letter := helpers.NewDeadLetter(msg, err, attempts)
+	if err := publishDeadLetter(ctx, letter); err != nil {
+		// Left uncommitted so the message is redelivered rather than lost
+		log.Errorf(i18n.Translate(ctx, "Failed to dead-letter message from %s partition %d offset %d: %v"), msg.Topic, msg.Partition, msg.Offset, err)
+		return err
+	}
+
+	log.Warnf(i18n.Translate(ctx, "Dead-lettered message from %s partition %d offset %d after %d attempts: %s"), msg.Topic, msg.Partition, msg.Offset, attempts, letter.Error)
+	return nil
+}
+
+func publishDeadLetter(ctx context.Context, letter models.DeadLetter) error {
+	value, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	return produce(ctx, &pubsub.Message{
+		Topic: kafkaConfig.DLQ.Topic(letter.Topic),
+		Key:   letter.Key,
+		Value: value,
+		Headers: map[string]string{
+			"source":      "order-service",
+			"X-Tenant-ID": letter.Headers["X-Tenant-ID"],
+		},
+	}).Wait(ctx)
+}
+
+// DeadLetterStoreHandler keeps the messages of the dead-letter topics in
+// Mongo so they can be listed and replayed.
+type DeadLetterStoreHandler struct{}
+
+func (h *DeadLetterStoreHandler) Process(ctx context.Context, msg *pubsub.Message) error {
+	var letter models.DeadLetter
+	if err := json.Unmarshal(msg.Value, &letter); err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to unmarshal dead letter: %v"), err)
+		return nil
+	}
+	return helpers.SaveDeadLetter(ctx, letter)
+}
+
+// DeadLetterReplayer lists dead letters and publishes them back to the
+// topic they failed on.
+type DeadLetterReplayer interface {
+	List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error)
+	Replay(ctx context.Context, filter models.DeadLetterFilter) (models.DeadLetterReplayResult, error)
+}
+
+type RealDeadLetterReplayer struct{}
+
+func (RealDeadLetterReplayer) List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	return helpers.FindDeadLetters(ctx, filter)
+}
+
+// Replay republishes the matching dead letters with their original key and
+// headers. A message that fails again is dead-lettered again as a new entry.
+func (RealDeadLetterReplayer) Replay(ctx context.Context, filter models.DeadLetterFilter) (models.DeadLetterReplayResult, error) {
+	var result models.DeadLetterReplayResult
+
+	letters, err := helpers.FindDeadLetters(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+	result.Matched = len(letters)
+
+	for _, letter := range letters {
+		if err := produce(ctx, helpers.ReplayMessage(letter)).Wait(ctx); err != nil {
+			log.Errorf(i18n.Translate(ctx, "Failed to replay dead letter %s: %v"), letter.DeadLetterID, err)
+			result.Failed = append(result.Failed, letter.DeadLetterID)
+			continue
+		}
+		if err := helpers.MarkDeadLetterReplayed(ctx, letter.DeadLetterID, time.Now()); err != nil {
+			log.Warnf(i18n.Translate(ctx, "Replayed dead letter %s but failed to mark it: %v"), letter.DeadLetterID, err)
+		}
+		result.Replayed++
+	}
+
+	log.Infof(i18n.Translate(ctx, "Replayed %d of %d dead letters"), result.Replayed, result.Matched)
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+// kafkaOrderEvents publishes order domain events to the order events topic,
+// keyed by order ID, encoded as the configured content type. The event type
+// and version are repeated in headers so consumers can filter without
+// decoding the payload. With the async producer an event is only queued;
+// failed deliveries are logged by the producer.
+type kafkaOrderEvents struct{}
+
+func (kafkaOrderEvents) PublishOrderEvent(ctx context.Context, event models.OrderEvent) error {
+	contentType := kafkaConfig.ContentType()
+	value, err := helpers.EncodeEvent(schemas.OrderEvent, event, contentType)
+	if err != nil {
+		return err
+	}
+
+	delivery := produce(ctx, &pubsub.Message{
+		Topic: kafkaConfig.Topics.OrderEvents,
+		Key:   event.Payload.Order.OrderID.String(),
+		Value: value,
+		Headers: map[string]string{
+			"source":                  "order-service",
+			"X-Tenant-ID":             event.TenantID.String(),
+			"event_id":                event.EventID.String(),
+			"event_type":              event.Type,
+			"event_version":           strconv.Itoa(event.Version),
+			helpers.ContentTypeHeader: contentType,
+		},
+	})
+
+	select {
+	case <-delivery.Done():
+		return delivery.Err()
+	default:
+		return nil
+	}
+}
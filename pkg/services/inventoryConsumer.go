@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+// InventoryUpdateHandler retries the held orders of a SKU/hub as soon as IMS
+// reports new stock there.
+type InventoryUpdateHandler struct{}
+
+func (h *InventoryUpdateHandler) Process(ctx context.Context, msg *pubsub.Message) error {
+	var update models.InventoryUpdate
+	if err := json.Unmarshal(msg.Value, &update); err != nil {
+		// Retrying cannot fix a malformed message
+		log.Errorf(i18n.Translate(ctx, "Failed to unmarshal inventory update: %v"), err)
+		return nil
+	}
+
+	retried, err := helpers.ReleaseOrdersForStock(ctx, update, retryAndNotify(helpers.LoadOrderBackoff(ctx)))
+	if err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to release orders for sku %s at hub %s: %v"), update.SKUID, update.HubID, err)
+		return err
+	}
+	if retried > 0 {
+		log.Infof(i18n.Translate(ctx, "Retried %d held orders for sku %s at hub %s"), retried, update.SKUID, update.HubID)
+	}
+	return nil
+}
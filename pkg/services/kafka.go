@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/omniful/go_commons/kafka"
+)
+
+// kafkaConfig is loaded when the producer or consumer starts.
+var kafkaConfig helpers.KafkaConfig
+
+// kafkaClientOptions loads the Kafka settings and returns the cluster options
+// shared by the producer and the consumer.
+func kafkaClientOptions(ctx context.Context, clientID func(helpers.KafkaConfig) string) ([]kafka.Option, error) {
+	cfg, err := helpers.LoadKafkaConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kafkaConfig = cfg
+
+	options := []kafka.Option{
+		kafka.WithBrokers(cfg.Brokers),
+		kafka.WithClientID(clientID(cfg)),
+		kafka.WithKafkaVersion(cfg.Version),
+	}
+	if cfg.SASL.Enabled {
+		options = append(options, kafka.WithSASL(cfg.SASL.Mechanism, cfg.SASL.Username, cfg.SASL.Password))
+	}
+
+	tlsConfig, err := cfg.TLS.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options = append(options, kafka.WithTLS(tlsConfig))
+	}
+	return options, nil
+}
@@ -2,10 +2,11 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 
 	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
 	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas"
 	"github.com/omniful/go_commons/i18n"
 	"github.com/omniful/go_commons/kafka"
 	"github.com/omniful/go_commons/log"
@@ -13,7 +14,13 @@ import (
 	"github.com/omniful/go_commons/pubsub/interceptor"
 )
 
-var kafkaConsumer *kafka.ConsumerClient
+var (
+	kafkaConsumer      *kafka.ConsumerClient
+	cancelSubscription context.CancelFunc
+
+	// kafkaInflight counts the messages being handled so shutdown can wait for them
+	kafkaInflight helpers.Inflight
+)
 
 // Implement message handler
 type MessageHandler struct{}
@@ -23,57 +30,146 @@ func (h *MessageHandler) Process(ctx context.Context, msg *pubsub.Message) error
 	return h.Handle(ctx, msg)
 }
 
-func InitKafkaConsumer(ctx context.Context) {
+func InitKafkaConsumer(ctx context.Context) error {
 	log.Infof(i18n.Translate(ctx, "Initializing Kafka consumer..."))
 
-	kafkaConsumer = kafka.NewConsumer(
-		kafka.WithBrokers([]string{"localhost:9092"}),
-		kafka.WithConsumerGroup("my-consumer-group"),
-		kafka.WithClientID("my-consumer"),
-		kafka.WithKafkaVersion("3.4.0"),
-	)
-
-	helpers.InitHTTPClient()
+	options, err := kafkaClientOptions(ctx, func(cfg helpers.KafkaConfig) string { return cfg.ConsumerClientID })
+	if err != nil {
+		return err
+	}
 
-	ReceiveOrder()
+	kafkaConsumer = kafka.NewConsumer(append(options, kafka.WithConsumerGroup(kafkaConfig.ConsumerGroup))...)
+	return nil
 }
 
 func GetKafkaConsumer() *kafka.ConsumerClient {
 	return kafkaConsumer
 }
 
-func ReceiveOrder() {
-	ctx := context.Background()
-
-	defer func() {
-		log.Infof(i18n.Translate(ctx, "Closing Kafka consumer"))
-		kafkaConsumer.Close()
-	}()
+// StartKafkaConsumer creates the consumer, registers the topic handlers and
+// subscribes. It returns once the subscription is running.
+func StartKafkaConsumer(ctx context.Context) error {
+	if err := InitKafkaConsumer(ctx); err != nil {
+		return err
+	}
+	ReceiveOrder(ctx)
+	return nil
+}
 
+func ReceiveOrder(ctx context.Context) {
 	log.Infof(i18n.Translate(ctx, "Attaching NewRelic interceptor to consumer"))
 	kafkaConsumer.SetInterceptor(interceptor.NewRelicInterceptor())
 
-	handler := &MessageHandler{}
-	topic := "order.created"
+	// Redeliveries of processed orders are skipped; inventory and
+	// invalidation messages are safe to apply again
+	deduper := helpers.NewMessageDeduper(RedisClient, kafkaConfig.DedupeTTL)
+	handler := dedupeHandler{deduper: deduper, next: &MessageHandler{}}
+	topic := kafkaConfig.Topics.OrderCreated
 
-	log.Infof(i18n.Translate(ctx, "Registering handler for topic: %s"), topic)
-	kafkaConsumer.RegisterHandler(topic, handler)
+	registerHandler(ctx, topic, handler)
+	registerHandler(ctx, kafkaConfig.Topics.ValidationInvalidated, &ValidationInvalidationHandler{})
+	registerHandler(ctx, kafkaConfig.Topics.InventoryUpdated, &InventoryUpdateHandler{})
+
+	// The subscription outlives the startup context and ends with StopKafkaConsumer
+	subscriptionCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	cancelSubscription = cancel
 
 	log.Infof(i18n.Translate(ctx, "Subscribing to topic: %s"), topic)
-	go kafkaConsumer.Subscribe(ctx)
+	go kafkaConsumer.Subscribe(subscriptionCtx)
+}
 
-	select {} // Block forever
+// registerHandler consumes topic with handler, retrying failed messages and
+// then moving them to the topic's dead-letter topic, which is consumed into
+// Mongo for replay.
+func registerHandler(ctx context.Context, topic string, handler pubsub.IPubSubMessageHandler) {
+	log.Infof(i18n.Translate(ctx, "Registering handler for topic: %s"), topic)
+	kafkaConsumer.RegisterHandler(topic, drainingHandler{deadLetterHandler{handler}})
+
+	dlq := kafkaConfig.DLQ.Topic(topic)
+	log.Infof(i18n.Translate(ctx, "Registering handler for topic: %s"), dlq)
+	kafkaConsumer.RegisterHandler(dlq, drainingHandler{&DeadLetterStoreHandler{}})
+}
+
+// StopKafkaConsumer stops fetching messages, waits until ctx expires for the
+// ones being handled and closes the consumer. Messages refused meanwhile are
+// left uncommitted for the next consumer of the partition.
+func StopKafkaConsumer(ctx context.Context) error {
+	if kafkaConsumer == nil {
+		return nil
+	}
+	cancelSubscription()
+
+	err := kafkaInflight.Drain(ctx)
+	if err != nil {
+		log.Warnf(i18n.Translate(ctx, "Kafka consumer stopped with %d messages in flight"), kafkaInflight.Count())
+	}
+
+	log.Infof(i18n.Translate(ctx, "Closing Kafka consumer"))
+	kafkaConsumer.Close()
+	return err
+}
+
+// drainingHandler refuses messages once shutdown has started and counts
+// the ones being handled.
+type drainingHandler struct {
+	next pubsub.IPubSubMessageHandler
+}
+
+func (h drainingHandler) Process(ctx context.Context, msg *pubsub.Message) error {
+	if !kafkaInflight.Begin() {
+		return helpers.ErrDraining
+	}
+	defer kafkaInflight.End()
+
+	return h.next.Process(ctx, msg)
+}
+
+// dedupeHandler skips messages that were already processed, e.g. when
+// redelivered after a rebalance before their offset was committed.
+type dedupeHandler struct {
+	deduper *helpers.MessageDeduper
+	next    pubsub.IPubSubMessageHandler
+}
+
+func (h dedupeHandler) Process(ctx context.Context, msg *pubsub.Message) error {
+	duplicate, err := h.deduper.Handle(ctx, msg, func(ctx context.Context) error {
+		return h.next.Process(ctx, msg)
+	})
+	if duplicate {
+		log.Infof(i18n.Translate(ctx, "Skipping already processed message %s"), helpers.MessageID(msg))
+	}
+	return err
 }
 
 func (h *MessageHandler) Handle(ctx context.Context, msg *pubsub.Message) error {
 	var order models.Order
-	err := json.Unmarshal(msg.Value, &order)
+	err := helpers.DecodeEvent(schemas.Order, msg.Value, helpers.ContentType(msg.Headers), &order)
 	if err != nil {
-		log.Errorf(i18n.Translate(ctx, "Failed to unmarshal Kafka message: %v"), err)
+		log.Errorf(i18n.Translate(ctx, "Failed to decode Kafka message: %v"), err)
+		return helpers.Permanent(err)
+	}
+
+	if err := helpers.EnsureOrderSaved(ctx, order); err != nil {
 		return err
 	}
 
-	helpers.CheckAndUpdateOrder(ctx, order)
+	// A redelivered message may carry a status the order has moved past, and
+	// the reservation attempt lives only in Mongo
+	stored, err := helpers.GetOrder(ctx, order.OrderID)
+	if err != nil {
+		return err
+	}
+	if stored.Status != "on_hold" && stored.Status != "pre_order" {
+		log.Infof(i18n.Translate(ctx, "Skipping order %s, already %s"), stored.OrderID, stored.Status)
+		return nil
+	}
+	order = stored
+
+	order.LastError = ""
+	order = helpers.CheckAndUpdateOrder(ctx, order)
+	if order.LastError != "" {
+		return errors.New(order.LastError)
+	}
 
 	tenantID := msg.Headers["X-Tenant-ID"]
 	if tenantID == "" {
@@ -82,6 +178,5 @@ func (h *MessageHandler) Handle(ctx context.Context, msg *pubsub.Message) error
 		NotifyTenantWebhook(ctx, tenantID, order)
 	}
 
-
 	return nil
-}
\ No newline at end of file
+}
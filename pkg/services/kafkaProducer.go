@@ -2,74 +2,132 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
 	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/aditya-goyal-omniful/oms/pkg/schemas"
 	"github.com/omniful/go_commons/i18n"
 	"github.com/omniful/go_commons/kafka"
 	"github.com/omniful/go_commons/log"
 	"github.com/omniful/go_commons/pubsub"
 )
 
-var kafkaProducer *kafka.ProducerClient
+var (
+	kafkaProducer *kafka.ProducerClient
+
+	// asyncProducer batches messages in front of kafkaProducer when
+	// kafka.producer.async.enabled is set
+	asyncProducer *helpers.AsyncProducer
+)
 
 type OrderPublisher interface {
-	Publish(order *models.Order, tenantID string)
+	Publish(ctx context.Context, order *models.Order, tenantID string) error
 }
 
 type RealPublisher struct{}
 
-func (RealPublisher) Publish(order *models.Order, tenantID string) {
-	PublishOrder(order, tenantID)
+func (RealPublisher) Publish(ctx context.Context, order *models.Order, tenantID string) error {
+	return PublishOrder(ctx, order, tenantID)
 }
 
-func InitKafkaProducer(ctx context.Context) {
+func InitKafkaProducer(ctx context.Context) error {
 	log.Infof(i18n.Translate(ctx, "Initializing Kafka producer"))
 
-	kafkaProducer = kafka.NewProducer(
-		kafka.WithBrokers([]string{"localhost:9092"}),
-		kafka.WithClientID("my-producer"),
-		kafka.WithKafkaVersion("3.4.0"),
-	)
+	options, err := kafkaClientOptions(ctx, func(cfg helpers.KafkaConfig) string { return cfg.ProducerClientID })
+	if err != nil {
+		return err
+	}
+
+	kafkaProducer = kafka.NewProducer(options...)
+	if kafkaConfig.Async.Enabled {
+		asyncProducer = helpers.NewAsyncProducer(kafkaProducer.Publish, helpers.AsyncProducerConfig{
+			Linger:     kafkaConfig.Async.Linger,
+			BatchSize:  kafkaConfig.Async.BatchSize,
+			BufferSize: kafkaConfig.Async.BufferSize,
+			OnDelivery: logFailedDelivery,
+		})
+		log.Infof(i18n.Translate(ctx, "Kafka producer batching up to %d messages for %v"), kafkaConfig.Async.BatchSize, kafkaConfig.Async.Linger)
+	}
+	helpers.Events = kafkaOrderEvents{}
+	log.Infof(i18n.Translate(ctx, "Kafka producer connected to %v"), kafkaConfig.Brokers)
+	return nil
 }
 
 func GetKafkaProducer() *kafka.ProducerClient {
 	return kafkaProducer
 }
 
-func CloseKafkaProducer(ctx context.Context) {
-	if kafkaProducer != nil {
-		log.Infof(i18n.Translate(ctx, "Closing Kafka producer"))
-		kafkaProducer.Close()
+// CloseKafkaProducer sends the messages still queued for batching, waiting
+// until ctx expires, and closes the producer.
+func CloseKafkaProducer(ctx context.Context) error {
+	if kafkaProducer == nil {
+		return nil
+	}
+
+	var err error
+	if asyncProducer != nil {
+		log.Infof(i18n.Translate(ctx, "Flushing %d queued Kafka messages"), asyncProducer.Pending())
+		if err = asyncProducer.Close(ctx); err != nil {
+			log.Warnf(i18n.Translate(ctx, "Kafka producer closed with %d messages unsent"), asyncProducer.Pending())
+		}
+	}
+
+	log.Infof(i18n.Translate(ctx, "Closing Kafka producer"))
+	kafkaProducer.Close()
+	return err
+}
+
+// produce sends msg through the async producer when it is enabled and
+// right away otherwise.
+func produce(ctx context.Context, msg *pubsub.Message) *helpers.Delivery {
+	if asyncProducer != nil {
+		return asyncProducer.Publish(ctx, msg)
+	}
+	return helpers.Delivered(kafkaProducer.Publish(ctx, msg))
+}
+
+func logFailedDelivery(ctx context.Context, msg *pubsub.Message, err error) {
+	if err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to deliver Kafka message %s to %s: %v"), msg.Key, msg.Topic, err)
 	}
 }
 
-func PublishOrder(order *models.Order, tenantID string) {
-	ctx := context.WithValue(context.Background(), "request_id", fmt.Sprintf("req-%s", order.OrderID))
+// PublishOrder publishes the order to the order created topic and waits
+// for it to be delivered.
+func PublishOrder(ctx context.Context, order *models.Order, tenantID string) error {
+	if err := PublishOrderAsync(ctx, order, tenantID).Wait(ctx); err != nil {
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to publish order:"))
+		return err
+	}
+	log.Infof(i18n.Translate(ctx, "Order published to Kafka successfully: OrderID=%s"), order.OrderID)
+	return nil
+}
 
-	// Marshal order into JSON
-	jsonBytes, err := json.Marshal(order)
+// PublishOrderAsync publishes the order to the order created topic without
+// waiting for it to be delivered.
+func PublishOrderAsync(ctx context.Context, order *models.Order, tenantID string) *helpers.Delivery {
+	ctx = context.WithValue(ctx, "request_id", fmt.Sprintf("req-%s", order.OrderID))
+
+	// Encode order as the configured content type, checked against its schema
+	contentType := kafkaConfig.ContentType()
+	value, err := helpers.EncodeEvent(schemas.Order, order, contentType)
 	if err != nil {
-		log.WithError(err).Error(i18n.Translate(ctx, "Failed to marshal order:"))
-		return
+		log.WithError(err).Error(i18n.Translate(ctx, "Failed to encode order:"))
+		return helpers.Delivered(err)
 	}
 
 	msg := &pubsub.Message{
-		Topic: "order.created",
+		Topic: kafkaConfig.Topics.OrderCreated,
 		Key:   fmt.Sprintf("order-%s", order.OrderID),
-		Value: jsonBytes,
+		Value: value,
 		Headers: map[string]string{
 			"source": "order-service",
 			"X-Tenant-ID": tenantID,
+			helpers.ContentTypeHeader: contentType,
 		},
 	}
 
 	log.Infof("Publishing order to topic: %s", msg.Topic)
-	err = kafkaProducer.Publish(ctx, msg)
-	if err != nil {
-		log.WithError(err).Error(i18n.Translate(ctx, "Failed to publish order:"))
-		panic(err)
-	}
-	log.Infof(i18n.Translate(ctx, "Order published to Kafka successfully: OrderID=%s"), order.OrderID)
-}
\ No newline at end of file
+	return produce(ctx, msg)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"github.com/omniful/go_commons/redis"
+)
+
+// JobLeader decides whether this replica runs a singleton background job.
+// With `leader.enabled` off every replica runs it.
+type JobLeader struct {
+	job     string
+	elector *helpers.LeaderElector
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewJobLeader starts competing for the job's Redis lease and renews it in
+// the background until ctx is done or Stop is called.
+func NewJobLeader(ctx context.Context, job string) *JobLeader {
+	leader := &JobLeader{job: job}
+	if !config.GetBool(ctx, "leader.enabled") {
+		return leader
+	}
+
+	ttl := config.GetDuration(ctx, "leader.lease_ttl")
+	if ttl <= 0 {
+		ttl = 15 * time.Second
+	}
+	renewEvery := config.GetDuration(ctx, "leader.renew_interval")
+	if renewEvery <= 0 || renewEvery >= ttl {
+		renewEvery = ttl / 3
+	}
+
+	leader.elector = helpers.NewLeaderElector(redisLeases{RedisClient}, job, config.GetString(ctx, "leader.replica_id"), ttl)
+	if _, err := leader.elector.TryAcquire(ctx); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Leader election for %s failed: %v"), job, err)
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	leader.stop = stop
+	leader.stopped = make(chan struct{})
+	go func() {
+		defer close(leader.stopped)
+		leader.elector.Run(runCtx, renewEvery)
+	}()
+
+	return leader
+}
+
+// Stop stops renewing the lease and gives it up, so another replica can take
+// the job over without waiting for it to expire.
+func (j *JobLeader) Stop() {
+	if j.elector == nil {
+		return
+	}
+	j.stop()
+	<-j.stopped
+}
+
+// ShouldRun reports whether this replica leads the job and no newer leader
+// has run it since, so the caller should do the work of this tick.
+func (j *JobLeader) ShouldRun(ctx context.Context) bool {
+	if j.elector == nil {
+		return true
+	}
+	if !j.elector.IsLeader() {
+		return false
+	}
+	if err := j.elector.Fence(ctx); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Skipping %s run: %v"), j.job, err)
+		return false
+	}
+	return true
+}
+
+// Fence checks that no newer leader has run the job, for long runs to call
+// before each batch of writes.
+func (j *JobLeader) Fence(ctx context.Context) error {
+	if j.elector == nil {
+		return nil
+	}
+	return j.elector.Fence(ctx)
+}
+
+// IsLeader is a cheap check that the lease is still held, for long runs to
+// stop early after losing it.
+func (j *JobLeader) IsLeader() bool {
+	return j.elector == nil || j.elector.IsLeader()
+}
+
+// Lua scripts comparing a lease's holder and changing it atomically
+const (
+	renewLeaseScript   = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`
+	releaseLeaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`
+)
+
+// redisLeases implements helpers.LeaseStore, running the compare operations
+// as Lua scripts.
+type redisLeases struct {
+	*redis.Client
+}
+
+func (r redisLeases) CompareAndExpire(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	result, err := r.Eval(ctx, renewLeaseScript, []string{key}, value, ttl.Milliseconds())
+	return scriptChanged(result), err
+}
+
+func (r redisLeases) CompareAndDelete(ctx context.Context, key, value string) (bool, error) {
+	result, err := r.Eval(ctx, releaseLeaseScript, []string{key}, value)
+	return scriptChanged(result), err
+}
+
+func scriptChanged(result interface{}) bool {
+	n, ok := result.(int64)
+	return ok && n == 1
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// StartPreorderReleaseWorker periodically moves pre-orders of launched SKUs
+// into the normal inventory flow, on one replica at a time.
+func StartPreorderReleaseWorker(ctx context.Context) {
+	interval := config.GetDuration(ctx, "preorder.release_interval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	StartWorker(ctx, "preorder-release", interval, false, releaseLaunchedPreorders)
+}
+
+// releaseLaunchedPreorders returns how many launches had their pre-orders
+// released.
+func releaseLaunchedPreorders(ctx context.Context) (int, error) {
+	launches, err := helpers.GetDueLaunches(ctx, time.Now())
+	if err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to fetch due SKU launches: %v"), err)
+		return 0, err
+	}
+
+	released := 0
+	var failed error
+	for _, launch := range launches {
+		if ctx.Err() != nil {
+			return released, ctx.Err()
+		}
+		if err := helpers.CheckFence(ctx); err != nil {
+			return released, err
+		}
+
+		log.Infof(i18n.Translate(ctx, "Releasing pre-orders for SKU %s"), launch.SKUID)
+		if err := helpers.ReleasePreorders(ctx, launch); err != nil {
+			log.Errorf(i18n.Translate(ctx, "Failed to release pre-orders for SKU %s: %v"), launch.SKUID, err)
+			failed = errors.Join(failed, err)
+			continue
+		}
+		released++
+	}
+	return released, failed
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// StartSagaRecoveryWorker resumes inventory sagas left half-finished, once at
+// startup and then periodically, on one replica at a time.
+func StartSagaRecoveryWorker(ctx context.Context) {
+	interval := config.GetDuration(ctx, "saga.recovery_interval")
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	staleAfter := config.GetDuration(ctx, "saga.stale_after")
+	if staleAfter <= 0 {
+		staleAfter = time.Minute
+	}
+
+	StartWorker(ctx, "saga-recovery", interval, true, func(ctx context.Context) (int, error) {
+		return recoverSagas(ctx, staleAfter)
+	})
+}
+
+func recoverSagas(ctx context.Context, staleAfter time.Duration) (int, error) {
+	recovered, err := helpers.RecoverSagas(ctx, staleAfter)
+	if err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to recover inventory sagas: %v"), err)
+		return recovered, err
+	}
+	if recovered > 0 {
+		log.Infof(i18n.Translate(ctx, "Recovered %d inventory sagas"), recovered)
+	}
+	return recovered, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// StartSLAMonitor periodically flags open orders that are close to or past
+// their ship-by deadline and notifies the tenant's webhook, on one replica at
+// a time so tenants are notified once.
+func StartSLAMonitor(ctx context.Context) {
+	interval := config.GetDuration(ctx, "sla.monitor_interval")
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	window := config.GetDuration(ctx, "sla.at_risk_window")
+	if window <= 0 {
+		window = 2 * time.Hour
+	}
+
+	StartWorker(ctx, "sla-monitor", interval, false, func(ctx context.Context) (int, error) {
+		return checkSLAs(ctx, window)
+	})
+}
+
+func checkSLAs(ctx context.Context, window time.Duration) (int, error) {
+	flagged, err := helpers.CheckSLAs(ctx, time.Now(), window, func(ctx context.Context, alert models.SLAAlert) {
+		log.Warnf(i18n.Translate(ctx, "Order %s is %s, ship by %s"), alert.OrderID, alert.SLAStatus, alert.ShipBy)
+		NotifyTenantWebhook(ctx, alert.TenantID.String(), alert)
+	})
+	if err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to check order SLAs: %v"), err)
+		return flagged, err
+	}
+	if flagged > 0 {
+		log.Infof(i18n.Translate(ctx, "Flagged %d orders approaching or past their SLA"), flagged)
+	}
+	return flagged, nil
+}
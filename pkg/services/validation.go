@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+	"github.com/omniful/go_commons/pubsub"
+)
+
+type ValidationInvalidationHandler struct{}
+
+func (h *ValidationInvalidationHandler) Process(ctx context.Context, msg *pubsub.Message) error {
+	var inv helpers.ValidationInvalidation
+	if err := json.Unmarshal(msg.Value, &inv); err != nil {
+		// Retrying cannot fix a malformed message
+		log.Errorf(i18n.Translate(ctx, "Failed to unmarshal validation invalidation: %v"), err)
+		return nil
+	}
+
+	if err := helpers.InvalidateValidation(ctx, inv); err != nil {
+		log.Errorf(i18n.Translate(ctx, "Failed to invalidate validation cache for tenant %s: %v"), inv.TenantID, err)
+		return err
+	}
+	return nil
+}
@@ -34,6 +34,14 @@ func InitRedis(ctx context.Context) {
 	log.Infof(i18n.Translate(ctx, "Redis initialized successfully!"))
 }
 
+func CloseRedis(ctx context.Context) error {
+	if RedisClient == nil {
+		return nil
+	}
+	log.Infof(i18n.Translate(ctx, "Closing Redis client"))
+	return RedisClient.Close()
+}
+
 func CacheWebhookURL(ctx context.Context, tenantID, url string) {
 	_, err := RedisClient.Set(ctx, "webhook:"+tenantID, url, 0)
 	if err != nil {
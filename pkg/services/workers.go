@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+var (
+	ErrWorkerNotFound = errors.New("worker not found")
+
+	errWorkerPaused  = errors.New("worker paused")
+	errNotLeader     = errors.New("lost leadership")
+	errWorkersHalted = errors.New("shutdown deadline passed")
+)
+
+// WorkerFunc does one run of a background worker and returns how many items
+// it processed. It should stop early once ctx is done.
+type WorkerFunc func(ctx context.Context) (int, error)
+
+// Worker runs a singleton background job every interval on the replica that
+// holds its lease. A run starts an interval after the previous one finished,
+// so runs never overlap. Pause and trigger requests are kept in Redis so any
+// replica can pass them to the leader.
+type Worker struct {
+	name     string
+	interval time.Duration
+	run      WorkerFunc
+	leader   *JobLeader
+	poll     time.Duration
+	isPaused func(ctx context.Context) bool
+
+	mu             sync.Mutex
+	running        bool
+	lastRunAt      time.Time
+	lastDuration   time.Duration
+	nextRunAt      time.Time
+	lastError      string
+	runs           int64
+	failures       int64
+	lastProcessed  int
+	totalProcessed int64
+}
+
+var (
+	workersMu sync.Mutex
+	workers   = make(map[string]*Worker)
+
+	// workerLoops tracks the running workers so shutdown can wait for them
+	workerLoops sync.WaitGroup
+
+	// haltWorkers cuts short the runs still going when shutdown runs out of time
+	haltCtx, haltWorkers = context.WithCancelCause(context.Background())
+)
+
+// StartWorker registers the worker and starts running it in the background
+// until ctx is done. A run in progress then still finishes (see StopWorkers)
+// before the worker gives up its lease. With runAtStart the first run is due
+// immediately.
+func StartWorker(ctx context.Context, name string, interval time.Duration, runAtStart bool, run WorkerFunc) *Worker {
+	w := &Worker{
+		name:     name,
+		interval: interval,
+		run:      run,
+		leader:   NewJobLeader(context.WithoutCancel(ctx), name),
+		poll:     pollInterval(ctx, interval),
+	}
+	w.isPaused = w.paused
+	w.nextRunAt = time.Now().Add(interval)
+	if runAtStart {
+		w.nextRunAt = time.Now()
+	}
+
+	workersMu.Lock()
+	workers[name] = w
+	workersMu.Unlock()
+
+	workerLoops.Add(1)
+	go w.loop(ctx)
+	return w
+}
+
+// StopWorkers waits for the workers, whose context must already be done, to
+// finish their current run and give up their leases. Runs still going when
+// ctx expires are cancelled.
+func StopWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		workerLoops.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Warnf(i18n.Translate(ctx, "Cancelling worker runs still going at shutdown"))
+		haltWorkers(errWorkersHalted)
+		return ctx.Err()
+	}
+}
+
+func pollInterval(ctx context.Context, interval time.Duration) time.Duration {
+	poll := config.GetDuration(ctx, "workers.poll_interval")
+	if poll <= 0 {
+		poll = 2 * time.Second
+	}
+	if poll > interval {
+		poll = interval
+	}
+	return poll
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	defer workerLoops.Done()
+	defer w.leader.Stop()
+
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.tick(ctx)
+		}
+	}
+}
+
+func (w *Worker) pausedKey() string  { return "oms:worker:" + w.name + ":paused" }
+func (w *Worker) triggerKey() string { return "oms:worker:" + w.name + ":trigger" }
+
+// redisFlag reports whether the flag key is set. Missing keys come back as
+// errors from the Redis client and count as unset.
+func redisFlag(ctx context.Context, key string) bool {
+	value, err := RedisClient.Get(ctx, key)
+	return err == nil && value != ""
+}
+
+func (w *Worker) paused(ctx context.Context) bool {
+	return redisFlag(ctx, w.pausedKey())
+}
+
+// takeTrigger consumes a pending trigger request.
+func (w *Worker) takeTrigger(ctx context.Context) bool {
+	if !redisFlag(ctx, w.triggerKey()) {
+		return false
+	}
+	if _, err := RedisClient.Del(ctx, w.triggerKey()); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to clear trigger of worker %s: %v"), w.name, err)
+	}
+	return true
+}
+
+func (w *Worker) tick(ctx context.Context) {
+	// Only the leader consumes triggers, so they reach the replica that runs the job
+	if !w.leader.IsLeader() {
+		return
+	}
+
+	triggered := w.takeTrigger(ctx)
+
+	w.mu.Lock()
+	due := !time.Now().Before(w.nextRunAt)
+	w.mu.Unlock()
+	if !due && !triggered {
+		return
+	}
+
+	// A trigger runs the worker once even while it is paused
+	if !triggered && w.isPaused(ctx) {
+		w.scheduleNext()
+		return
+	}
+	if !w.leader.ShouldRun(ctx) {
+		w.scheduleNext()
+		return
+	}
+
+	w.runOnce(ctx, triggered)
+}
+
+func (w *Worker) scheduleNext() {
+	w.mu.Lock()
+	w.nextRunAt = time.Now().Add(w.interval)
+	w.mu.Unlock()
+}
+
+// runOnce runs the worker, cancelling the run if it is paused or this
+// replica loses the lease half way through. The run can check its fencing
+// token through helpers.CheckFence. A triggered run of a paused
+// worker is not cancelled for the pause it was triggered through. Shutdown
+// lets the run finish unless it takes longer than the shutdown deadline.
+func (w *Worker) runOnce(parent context.Context, triggered bool) {
+	started := time.Now()
+	w.mu.Lock()
+	w.running = true
+	w.lastRunAt = started
+	w.mu.Unlock()
+
+	ctx, cancel := context.WithCancelCause(context.WithoutCancel(parent))
+	ctx = helpers.WithFence(ctx, w.leader.Fence)
+	stopHalt := context.AfterFunc(haltCtx, func() { cancel(context.Cause(haltCtx)) })
+	defer stopHalt()
+
+	ignorePause := triggered && w.isPaused(ctx)
+	done := make(chan struct{})
+	go w.watch(ctx, cancel, done, ignorePause)
+
+	processed, err := w.run(ctx)
+	close(done)
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+		err = cause
+	}
+	cancel(nil)
+
+	finished := time.Now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.running = false
+	w.lastDuration = finished.Sub(started)
+	w.nextRunAt = finished.Add(w.interval)
+	w.runs++
+	w.lastProcessed = processed
+	w.totalProcessed += int64(processed)
+	w.lastError = ""
+	if err != nil {
+		if !errors.Is(err, errWorkerPaused) {
+			w.failures++
+		}
+		w.lastError = err.Error()
+		log.Warnf(i18n.Translate(parent, "Worker %s run ended with: %v"), w.name, err)
+	}
+}
+
+func (w *Worker) watch(ctx context.Context, cancel context.CancelCauseFunc, done <-chan struct{}, ignorePause bool) {
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !ignorePause && w.isPaused(ctx) {
+				cancel(errWorkerPaused)
+				return
+			}
+			if !w.leader.IsLeader() {
+				cancel(errNotLeader)
+				return
+			}
+		}
+	}
+}
+
+// Status reports the worker's schedule and run statistics.
+func (w *Worker) Status(ctx context.Context) models.WorkerStatus {
+	paused := w.paused(ctx)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := models.WorkerStatus{
+		Name:           w.name,
+		Interval:       w.interval.String(),
+		Paused:         paused,
+		Running:        w.running,
+		Leader:         w.leader.IsLeader(),
+		LastError:      w.lastError,
+		Runs:           w.runs,
+		Failures:       w.failures,
+		LastProcessed:  w.lastProcessed,
+		TotalProcessed: w.totalProcessed,
+	}
+	if !w.lastRunAt.IsZero() {
+		lastRunAt := w.lastRunAt
+		status.LastRunAt = &lastRunAt
+		status.LastDuration = w.lastDuration.String()
+	}
+	if status.Leader && !paused {
+		nextRunAt := w.nextRunAt
+		status.NextRunAt = &nextRunAt
+	}
+	return status
+}
+
+func getWorker(name string) (*Worker, error) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+
+	w, ok := workers[name]
+	if !ok {
+		return nil, ErrWorkerNotFound
+	}
+	return w, nil
+}
+
+// WorkerAdmin lists and controls the background workers.
+type WorkerAdmin interface {
+	List(ctx context.Context) []models.WorkerStatus
+	Pause(ctx context.Context, name string) (models.WorkerStatus, error)
+	Resume(ctx context.Context, name string) (models.WorkerStatus, error)
+	Trigger(ctx context.Context, name string) (models.WorkerStatus, error)
+}
+
+type RealWorkerAdmin struct{}
+
+func (RealWorkerAdmin) List(ctx context.Context) []models.WorkerStatus {
+	workersMu.Lock()
+	all := make([]*Worker, 0, len(workers))
+	for _, w := range workers {
+		all = append(all, w)
+	}
+	workersMu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].name < all[j].name })
+
+	statuses := make([]models.WorkerStatus, 0, len(all))
+	for _, w := range all {
+		statuses = append(statuses, w.Status(ctx))
+	}
+	return statuses
+}
+
+// Pause stops the worker on every replica until it is resumed; a run in
+// progress is cancelled.
+func (RealWorkerAdmin) Pause(ctx context.Context, name string) (models.WorkerStatus, error) {
+	w, err := getWorker(name)
+	if err != nil {
+		return models.WorkerStatus{}, err
+	}
+	if _, err := RedisClient.Set(ctx, w.pausedKey(), "1", 0); err != nil {
+		return models.WorkerStatus{}, err
+	}
+	log.Infof(i18n.Translate(ctx, "Paused worker %s"), name)
+	return w.Status(ctx), nil
+}
+
+func (RealWorkerAdmin) Resume(ctx context.Context, name string) (models.WorkerStatus, error) {
+	w, err := getWorker(name)
+	if err != nil {
+		return models.WorkerStatus{}, err
+	}
+	if _, err := RedisClient.Del(ctx, w.pausedKey()); err != nil {
+		return models.WorkerStatus{}, err
+	}
+	log.Infof(i18n.Translate(ctx, "Resumed worker %s"), name)
+	return w.Status(ctx), nil
+}
+
+// Trigger asks the leader to run the worker at its next poll, even while
+// paused. Requests not picked up within a minute are dropped.
+func (RealWorkerAdmin) Trigger(ctx context.Context, name string) (models.WorkerStatus, error) {
+	w, err := getWorker(name)
+	if err != nil {
+		return models.WorkerStatus{}, err
+	}
+	if _, err := RedisClient.Set(ctx, w.triggerKey(), "1", time.Minute); err != nil {
+		return models.WorkerStatus{}, err
+	}
+	log.Infof(i18n.Translate(ctx, "Triggered worker %s"), name)
+	return w.Status(ctx), nil
+}
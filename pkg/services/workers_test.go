@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWorkerRunWhilePaused(t *testing.T) {
+	tests := []struct {
+		name          string
+		triggered     bool
+		wantProcessed int
+		wantError     string
+	}{
+		{"Triggered Run Completes", true, 1, ""},
+		{"Scheduled Run Is Cancelled", false, 0, errWorkerPaused.Error()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Worker{
+				name:     "test-worker",
+				interval: time.Hour,
+				leader:   &JobLeader{job: "test-worker"},
+				poll:     5 * time.Millisecond,
+				isPaused: func(ctx context.Context) bool { return true },
+				// Outlasts several pause checks unless cancelled
+				run: func(ctx context.Context) (int, error) {
+					select {
+					case <-time.After(50 * time.Millisecond):
+						return 1, nil
+					case <-ctx.Done():
+						return 0, context.Cause(ctx)
+					}
+				},
+			}
+
+			w.runOnce(context.Background(), tc.triggered)
+
+			if w.lastProcessed != tc.wantProcessed || w.lastError != tc.wantError {
+				t.Errorf("expected %d processed and error %q, got %d and %q", tc.wantProcessed, tc.wantError, w.lastProcessed, w.lastError)
+			}
+		})
+	}
+}
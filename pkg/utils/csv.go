@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
 	"github.com/aditya-goyal-omniful/oms/pkg/models"
 	"github.com/aditya-goyal-omniful/oms/pkg/services"
 	"github.com/google/uuid"
@@ -140,6 +141,8 @@ func ParseCSV(tmpFile string, ctx context.Context, collection *mongo.Collection)
 			break
 		}
 
+		var rows [][]string
+		var orders []*models.Order
 		for _, row := range records {
 			log.Infof(i18n.Translate(ctx, "CSV Row: %v"), row)
 
@@ -150,13 +153,39 @@ func ParseCSV(tmpFile string, ctx context.Context, collection *mongo.Collection)
 				continue
 			}
 
-			if err := validateAndSaveOrder(ctx, order, collection); err != nil {
-				log.Warnf(i18n.Translate(ctx, "Validation or save failed: %v"), err)
+			expanded, err := helpers.ExpandKitOrder(ctx, *order)
+			if err != nil {
+				log.Warnf(i18n.Translate(ctx, "Failed to expand kit order: %v"), err)
 				invalid = append(invalid, row)
 				continue
 			}
+			*order = expanded
+
+			rows = append(rows, row)
+			orders = append(orders, order)
+		}
+
+		// One IMS round-trip for the unique hub/SKU pairs of the whole batch
+		validateLine := validateBatch(ctx, orders)
+
+		var published []*models.Order
+		var deliveries []*helpers.Delivery
+		for i, order := range orders {
+			if err := validateAndSaveOrder(ctx, order, collection, validateLine); err != nil {
+				log.Warnf(i18n.Translate(ctx, "Validation or save failed: %v"), err)
+				invalid = append(invalid, rows[i])
+				continue
+			}
 
-			services.PublishOrder(order, order.TenantID.String())
+			published = append(published, order)
+			deliveries = append(deliveries, services.PublishOrderAsync(ctx, order, order.TenantID.String()))
+		}
+
+		// Saved orders that could not be published stay on hold for the retry worker
+		for i, delivery := range deliveries {
+			if err := delivery.Wait(ctx); err != nil {
+				log.Errorf(i18n.Translate(ctx, "Failed to publish order %s: %v"), published[i].OrderID, err)
+			}
 		}
 	}
 
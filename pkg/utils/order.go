@@ -4,66 +4,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
-
-	nethttp "net/http"
 
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
 	"github.com/aditya-goyal-omniful/oms/pkg/models"
 	"github.com/google/uuid"
-	"github.com/omniful/go_commons/config"
-	"github.com/omniful/go_commons/http"
 	"github.com/omniful/go_commons/i18n"
 	"github.com/omniful/go_commons/log"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type ValidationResponse struct {
-	IsValid bool   `json:"is_valid"`
-	Error   string `json:"error"`
-}
-
-var client *http.Client
-var err error
-
-func InitHTTPClient(ctx context.Context) {
-	transport := &nethttp.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-	}
-
-	serviceName := config.GetString(ctx, "client.serviceName")
-	baseURL := config.GetString(ctx, "client.baseURL")
-	timeout := config.GetDuration(ctx, "http.timeout")
-	client, err = http.NewHTTPClient(
-		serviceName,
-		baseURL,
-		transport,
-		http.WithTimeout(timeout),
-	)
-}
-
-
-var ValidateWithIMS = func(ctx context.Context, hubID, skuID uuid.UUID) bool {
-	req := &http.Request{
-		Url: fmt.Sprintf("validators/validate_order/%s/%s", hubID, skuID),
-		Headers: map[string][]string{
-			"Content-Type": {"application/json"},
-		},
-		Timeout: 5 * time.Second,
-	}
-
-	var response ValidationResponse
-	_, err := client.Get(req, &response)
+var ValidateWithIMS = func(ctx context.Context, hubID, skuID uuid.UUID) (bool, error) {
+	valid, err := helpers.Inventory.ValidateOrderLine(ctx, hubID, skuID)
 	if err != nil {
 		log.Errorf(i18n.Translate(ctx, "Failed to call IMS validate API: %v"), err)
-		return false
+		return false, err
 	}
-
-	return response.IsValid
+	return valid, nil
 }
 
 
 func ValidateOrder(ctx context.Context, order *models.Order) error {
+	return validateOrder(ctx, order, validateLineWithIMS)
+}
+
+func validateLineWithIMS(ctx context.Context, order *models.Order, line models.Order) (bool, error) {
+	return helpers.CachedValidation(ctx, order.TenantID, "pair", func() (bool, error) {
+		return ValidateWithIMS(ctx, line.HubID, line.SKUID)
+	}, line.HubID, line.SKUID)
+}
+
+func validateOrder(ctx context.Context, order *models.Order, validateLine lineValidator) error {
 	if order.OrderID == uuid.Nil {
 		return errors.New("invalid OrderID")
 	}
@@ -86,9 +56,11 @@ func ValidateOrder(ctx context.Context, order *models.Order) error {
 		return errors.New("invalid Price")
 	}
 
-	valid := ValidateWithIMS(ctx, order.HubID, order.SKUID)
-	if !valid {
-		return errors.New(i18n.Translate(ctx, "invalid HubID or SKUID"))
+	for _, line := range helpers.ComponentOrders(*order) {
+		valid, err := validateLine(ctx, order, line)
+		if err != nil || !valid {
+			return errors.New(i18n.Translate(ctx, "invalid HubID or SKUID"))
+		}
 	}
 
 	return nil
@@ -97,22 +69,45 @@ func ValidateOrder(ctx context.Context, order *models.Order) error {
 
 func saveOrder(ctx context.Context, order *models.Order, collection *mongo.Collection) error {
 	log.Infof(i18n.Translate(ctx, "Attempting to insert order into DB: %+v"), order)
-	order.Status = "on_hold"
+	if order.Status == "" {
+		order.Status = "on_hold"
+	}
 	_, err := collection.InsertOne(ctx, order)
 	if err != nil {
 		log.Errorf(i18n.Translate(ctx, "Mongo insert error: %v"), err)
 		return fmt.Errorf(i18n.Translate(ctx, "failed to insert order: %w"), err)
 	}
 	log.Infof(i18n.Translate(ctx, "Order successfully inserted: %v"), order.OrderID)
+	helpers.EmitOrderEvent(ctx, models.EventOrderCreated, *order, "")
 	return nil
 }
 
 
-func validateAndSaveOrder(ctx context.Context, order *models.Order, collection *mongo.Collection) error {
-	if err := ValidateOrder(ctx, order); err != nil {
+// validateAndSaveOrder expects kit orders to be expanded already so that
+// their component lines were part of the batch validation.
+func validateAndSaveOrder(ctx context.Context, order *models.Order, collection *mongo.Collection, validateLine lineValidator) error {
+	if err := validateOrder(ctx, order, validateLine); err != nil {
+		return err
+	}
+
+	status, err := helpers.AdmitOrder(ctx, *order)
+	if err != nil {
 		return err
 	}
+	order.Status = status
+
+	if planned, err := helpers.PlanShipBy(ctx, *order); err != nil {
+		log.Warnf(i18n.Translate(ctx, "Failed to compute ship-by deadline for order %s: %v"), order.OrderID, err)
+	} else {
+		*order = planned
+	}
+
 	if err := saveOrder(ctx, order, collection); err != nil {
+		if order.Status == "pre_order" {
+			if err := helpers.ReleasePreorder(ctx, *order); err != nil {
+				log.Warnf(i18n.Translate(ctx, "Failed to release pre-order %s: %v"), order.OrderID, err)
+			}
+		}
 		return err
 	}
 	return nil
@@ -15,8 +15,8 @@ var validateWithIMSStub func(ctx context.Context, hubID, skuID uuid.UUID) bool
 
 func init() {
 	// Replace the original function with the stub
-	ValidateWithIMS = func(ctx context.Context, hubID, skuID uuid.UUID) bool {
-		return validateWithIMSStub(ctx, hubID, skuID)
+	ValidateWithIMS = func(ctx context.Context, hubID, skuID uuid.UUID) (bool, error) {
+		return validateWithIMSStub(ctx, hubID, skuID), nil
 	}
 }
 
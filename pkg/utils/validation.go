@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+	"github.com/omniful/go_commons/config"
+	"github.com/omniful/go_commons/i18n"
+	"github.com/omniful/go_commons/log"
+)
+
+// ValidationPair is a hub/SKU combination checked by the IMS order validator.
+type ValidationPair = helpers.ValidationPair
+
+// lineValidator validates one component line of an order.
+type lineValidator func(ctx context.Context, order *models.Order, line models.Order) (bool, error)
+
+// Set once IMS reports it has no bulk endpoint so later batches go straight
+// to single calls.
+var bulkValidationUnsupported atomic.Bool
+
+// ValidateBatchWithIMS validates many hub/SKU pairs in one IMS call. Pairs
+// missing from the result could not be answered.
+var ValidateBatchWithIMS = func(ctx context.Context, pairs []ValidationPair) (map[ValidationPair]bool, error) {
+	return helpers.Inventory.ValidateOrderLines(ctx, pairs)
+}
+
+// ValidatePairs asks IMS about every pair, using the bulk endpoint when
+// available and concurrent single calls otherwise. Only definite answers are
+// returned; pairs IMS could not answer are absent.
+func ValidatePairs(ctx context.Context, pairs []ValidationPair) map[ValidationPair]bool {
+	if len(pairs) == 0 {
+		return map[ValidationPair]bool{}
+	}
+
+	if !bulkValidationUnsupported.Load() {
+		results, err := ValidateBatchWithIMS(ctx, pairs)
+		if err == nil {
+			return results
+		}
+		if errors.Is(err, helpers.ErrBulkValidationUnsupported) {
+			log.Infof(i18n.Translate(ctx, "IMS bulk validation unavailable, falling back to single calls"))
+			bulkValidationUnsupported.Store(true)
+		} else {
+			log.Warnf(i18n.Translate(ctx, "IMS bulk validation failed, falling back to single calls: %v"), err)
+		}
+	}
+
+	return validatePairsConcurrently(ctx, pairs)
+}
+
+func validatePairsConcurrently(ctx context.Context, pairs []ValidationPair) map[ValidationPair]bool {
+	concurrency := config.GetInt(ctx, "csv.validation_concurrency")
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[ValidationPair]bool, len(pairs))
+		slots   = make(chan struct{}, concurrency)
+	)
+
+	for _, pair := range pairs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			valid, err := ValidateWithIMS(ctx, pair.HubID, pair.SKUID)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			results[pair] = valid
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+type tenantPair struct {
+	TenantID uuid.UUID
+	ValidationPair
+}
+
+// validateBatch resolves IMS validation for every component line of a CSV
+// batch up front: unique pairs are looked up in the validation cache and the
+// rest are validated together. The returned validator answers from memory.
+func validateBatch(ctx context.Context, orders []*models.Order) lineValidator {
+	known := make(map[tenantPair]bool)
+	var missing []tenantPair
+	seen := make(map[tenantPair]bool)
+
+	for _, order := range orders {
+		for _, line := range helpers.ComponentOrders(*order) {
+			key := tenantPair{order.TenantID, ValidationPair{HubID: line.HubID, SKUID: line.SKUID}}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			if valid, found := helpers.LookupValidation(ctx, key.TenantID, "pair", key.HubID, key.SKUID); found {
+				known[key] = valid
+				continue
+			}
+			missing = append(missing, key)
+		}
+	}
+
+	// The IMS validator is not tenant scoped, so tenants share a pair's answer
+	var pairs []ValidationPair
+	unique := make(map[ValidationPair]bool)
+	for _, key := range missing {
+		if !unique[key.ValidationPair] {
+			unique[key.ValidationPair] = true
+			pairs = append(pairs, key.ValidationPair)
+		}
+	}
+
+	results := ValidatePairs(ctx, pairs)
+	for _, key := range missing {
+		if valid, ok := results[key.ValidationPair]; ok {
+			known[key] = valid
+			helpers.StoreValidation(ctx, key.TenantID, "pair", valid, key.HubID, key.SKUID)
+		}
+	}
+
+	log.Infof(i18n.Translate(ctx, "Validated %d hub/SKU pairs for %d CSV rows with %d IMS lookups"), len(seen), len(orders), len(pairs))
+
+	return func(ctx context.Context, order *models.Order, line models.Order) (bool, error) {
+		valid, ok := known[tenantPair{order.TenantID, ValidationPair{HubID: line.HubID, SKUID: line.SKUID}}]
+		if !ok {
+			return false, errors.New(i18n.Translate(ctx, "IMS validation unavailable"))
+		}
+		return valid, nil
+	}
+}
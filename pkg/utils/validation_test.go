@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aditya-goyal-omniful/oms/pkg/helpers"
+	"github.com/aditya-goyal-omniful/oms/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestValidateBatch(t *testing.T) {
+	ctx := context.Background()
+	tenant := uuid.New()
+	hub, validSKU, invalidSKU := uuid.New(), uuid.New(), uuid.New()
+
+	orders := []*models.Order{
+		{TenantID: tenant, HubID: hub, SKUID: validSKU},
+		{TenantID: tenant, HubID: hub, SKUID: validSKU},
+		{TenantID: tenant, HubID: hub, SKUID: invalidSKU},
+	}
+	answers := map[ValidationPair]bool{
+		{HubID: hub, SKUID: validSKU}:   true,
+		{HubID: hub, SKUID: invalidSKU}: false,
+	}
+
+	originalBatch, originalSingle := ValidateBatchWithIMS, ValidateWithIMS
+	defer func() {
+		ValidateBatchWithIMS, ValidateWithIMS = originalBatch, originalSingle
+		bulkValidationUnsupported.Store(false)
+	}()
+
+	tests := []struct {
+		name             string
+		bulkErr          error
+		wantBulk         int
+		wantSingles      int
+		unsupportedAfter bool
+	}{
+		{"Bulk endpoint", nil, 1, 0, false},
+		{"Bulk endpoint missing", helpers.ErrBulkValidationUnsupported, 1, 2, true},
+		{"Bulk call fails", errors.New("timeout"), 1, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bulkValidationUnsupported.Store(false)
+
+			var mu sync.Mutex
+			bulkCalls, singleCalls := 0, 0
+			ValidateBatchWithIMS = func(ctx context.Context, pairs []ValidationPair) (map[ValidationPair]bool, error) {
+				bulkCalls++
+				if len(pairs) != 2 {
+					t.Errorf("expected 2 unique pairs, got %d", len(pairs))
+				}
+				if tt.bulkErr != nil {
+					return nil, tt.bulkErr
+				}
+				return answers, nil
+			}
+			ValidateWithIMS = func(ctx context.Context, hubID, skuID uuid.UUID) (bool, error) {
+				mu.Lock()
+				singleCalls++
+				mu.Unlock()
+				return answers[ValidationPair{HubID: hubID, SKUID: skuID}], nil
+			}
+
+			validateLine := validateBatch(ctx, orders)
+
+			if bulkCalls != tt.wantBulk || singleCalls != tt.wantSingles {
+				t.Errorf("expected %d bulk and %d single calls, got %d and %d", tt.wantBulk, tt.wantSingles, bulkCalls, singleCalls)
+			}
+			if bulkValidationUnsupported.Load() != tt.unsupportedAfter {
+				t.Errorf("expected bulk unsupported=%v", tt.unsupportedAfter)
+			}
+
+			for i, order := range orders {
+				valid, err := validateLine(ctx, order, *order)
+				if err != nil || valid != (order.SKUID == validSKU) {
+					t.Errorf("row %d: unexpected result valid=%v err=%v", i, valid, err)
+				}
+			}
+		})
+	}
+}